@@ -0,0 +1,45 @@
+package i2b2datasource
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrClosed is returned by Query once Close has been called, so a caller
+// racing a plugin unload gets a clear error instead of an arbitrary
+// i2b2/database failure.
+var ErrClosed = fmt.Errorf("i2b2datasource: data source is closed")
+
+// Close stops ds from accepting new operations and releases the Postgres
+// connection pool, so GeCo can cleanly unload this data source. It blocks
+// until operations already in flight (Query calls that acquired closeMu
+// before Close did) have returned, or until ctx is done, whichever comes
+// first; in the latter case the in-flight operations are left to finish on
+// their own and the connection pool is released once they do.
+//
+// Close is idempotent: calling it more than once is a no-op after the
+// first call.
+func (ds *I2b2DataSource) Close(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		ds.closeMu.Lock()
+		defer ds.closeMu.Unlock()
+		if ds.closed {
+			done <- nil
+			return
+		}
+		ds.closed = true
+		if ds.db == nil {
+			done <- nil
+			return
+		}
+		done <- ds.db.Close()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,546 @@
+package i2b2datasource
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AddCohortHandler persists a named cohort so it can be referenced by later
+// queries, and returns the patient count of its i2b2 patient set so a
+// caller that just saved the cohort can display its size without a
+// separate round-trip.
+//
+// Parameters:
+//   - name (string, required): the cohort name.
+//   - patientSetID (string, required): the i2b2 patient set ID to associate
+//     with the cohort.
+//   - idempotencyKey (string, optional): identifies this add so a client
+//     that retries after timing out on the response (but whose first call
+//     actually succeeded) gets back the cohort that call created instead of
+//     creating a duplicate. Unset derives a key from name and
+//     patientSetID, so a plain retry of the same parameters is safe even
+//     without the caller tracking a key itself; passing an explicit key
+//     matters when the same name/patientSetID pair should legitimately be
+//     addable more than once.
+func (ds *I2b2DataSource) AddCohortHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	name := parameters["name"]
+	patientSetID := parameters["patientSetID"]
+	if name == "" || patientSetID == "" {
+		return nil, fmt.Errorf("i2b2datasource: AddCohort requires name and patientSetID")
+	}
+	idempotencyKey := parameters["idempotencyKey"]
+	if idempotencyKey == "" {
+		idempotencyKey = deriveCohortIdempotencyKey(name, patientSetID)
+	}
+
+	patientCount, err := ds.i2b2Client.GetPatientSetSize(ctx, patientSetID)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: resolving patient count for cohort %q: %w", name, err)
+	}
+
+	var resultName string
+	var resultPatientCount int
+	var createdAt, updatedAt time.Time
+	err = ds.db.WithTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`SELECT name, patient_count, created_at, updated_at FROM cohort WHERE idempotency_key = $1`,
+			idempotencyKey,
+		).Scan(&resultName, &resultPatientCount, &createdAt, &updatedAt)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("i2b2datasource: checking idempotency key for cohort %q: %w", name, err)
+		}
+
+		if err := tx.QueryRowContext(ctx,
+			`INSERT INTO cohort (name, patient_set_id, idempotency_key, patient_count) VALUES ($1, $2, $3, $4) RETURNING created_at, updated_at`,
+			name, patientSetID, idempotencyKey, patientCount,
+		).Scan(&createdAt, &updatedAt); err != nil {
+			return fmt.Errorf("i2b2datasource: adding cohort %q: %w", name, err)
+		}
+		resultName, resultPatientCount = name, patientCount
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameAddCohort: map[string]interface{}{
+			"name":         resultName,
+			"patientCount": resultPatientCount,
+			"createdAt":    formatCohortTimestamp(createdAt),
+			"updatedAt":    formatCohortTimestamp(updatedAt),
+		},
+	}, nil
+}
+
+// formatCohortTimestamp renders a cohort timestamp column in UTC RFC3339,
+// the consistent format cohort handlers use for created_at/updated_at/
+// deleted_at so a UI can parse and sort them without per-field format
+// detection.
+func formatCohortTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// deriveCohortIdempotencyKey derives an AddCohortHandler idempotency key
+// from name and patientSetID for callers that don't track their own key, so
+// a plain retry with the same parameters hashes to the same key and is
+// recognized as the same add rather than a new one.
+func deriveCohortIdempotencyKey(name, patientSetID string) string {
+	sum := sha256.Sum256([]byte(name + "\x00" + patientSetID))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetCohortsHandler lists the cohorts previously added by the user.
+//
+// Parameters:
+//   - includeDeleted (string, optional): when "true", also lists
+//     soft-deleted cohorts (see DeleteCohortsHandler), each with a
+//     "deletedAt" timestamp; excluded by default.
+//   - namePrefix (string, optional): restricts to cohorts whose name
+//     starts with this, matched case-insensitively.
+//   - createdAfter, createdBefore (string, optional): RFC3339 timestamps
+//     bounding the cohort's creation date, inclusive.
+//   - minPatientCount, maxPatientCount (string, optional): bound the
+//     cohort's patient count (as recorded when it was added or last
+//     repointed at a different patient set via UpdateCohortHandler; not
+//     re-resolved against i2b2 live).
+//   - sortBy (string, optional): one of "name", "createdAt",
+//     "patientCount"; defaults to "createdAt".
+//   - sortOrder (string, optional): "asc" or "desc"; defaults to "desc",
+//     i.e. newest cohorts first.
+//
+// Filtering and sorting are applied in the SQL query rather than in
+// memory, so they scale with the cohort table instead of the amount of
+// data transferred to this process.
+func (ds *I2b2DataSource) GetCohortsHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	query, args, err := buildGetCohortsQuery(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ds.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: listing cohorts: %w", err)
+	}
+	defer rows.Close()
+
+	var cohorts []map[string]interface{}
+	for rows.Next() {
+		var name, patientSetID string
+		var patientCount int
+		var createdAt, updatedAt time.Time
+		var deletedAt sql.NullTime
+		if err := rows.Scan(&name, &patientSetID, &patientCount, &createdAt, &updatedAt, &deletedAt); err != nil {
+			return nil, fmt.Errorf("i2b2datasource: scanning cohort row: %w", err)
+		}
+		var deletedAtOutput interface{}
+		if deletedAt.Valid {
+			deletedAtOutput = formatCohortTimestamp(deletedAt.Time)
+		}
+		cohorts = append(cohorts, map[string]interface{}{
+			"name":         name,
+			"patientSetID": patientSetID,
+			"patientCount": patientCount,
+			"createdAt":    formatCohortTimestamp(createdAt),
+			"updatedAt":    formatCohortTimestamp(updatedAt),
+			"deletedAt":    deletedAtOutput,
+		})
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameGetCohorts: cohorts,
+	}, nil
+}
+
+// cohortSortColumns allowlists the columns GetCohortsHandler's sortBy
+// parameter may select, since it's interpolated directly into the query's
+// ORDER BY clause rather than passed as a placeholder argument.
+var cohortSortColumns = map[string]string{
+	"name":         "name",
+	"createdAt":    "created_at",
+	"patientCount": "patient_count",
+}
+
+// buildGetCohortsQuery builds GetCohortsHandler's SQL query and its
+// positional argument list from parameters, kept separate from the
+// handler so the filtering/sorting logic is testable without a database.
+func buildGetCohortsQuery(parameters map[string]string) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	placeholder := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if parameters["includeDeleted"] != "true" {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if prefix := parameters["namePrefix"]; prefix != "" {
+		conditions = append(conditions, fmt.Sprintf("name ILIKE %s", placeholder(prefix+"%")))
+	}
+	if raw := parameters["createdAfter"]; raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return "", nil, fmt.Errorf("i2b2datasource: invalid createdAfter %q: %w", raw, err)
+		}
+		conditions = append(conditions, fmt.Sprintf("created_at >= %s", placeholder(t)))
+	}
+	if raw := parameters["createdBefore"]; raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return "", nil, fmt.Errorf("i2b2datasource: invalid createdBefore %q: %w", raw, err)
+		}
+		conditions = append(conditions, fmt.Sprintf("created_at <= %s", placeholder(t)))
+	}
+	if raw := parameters["minPatientCount"]; raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return "", nil, fmt.Errorf("i2b2datasource: invalid minPatientCount %q", raw)
+		}
+		conditions = append(conditions, fmt.Sprintf("patient_count >= %s", placeholder(n)))
+	}
+	if raw := parameters["maxPatientCount"]; raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return "", nil, fmt.Errorf("i2b2datasource: invalid maxPatientCount %q", raw)
+		}
+		conditions = append(conditions, fmt.Sprintf("patient_count <= %s", placeholder(n)))
+	}
+
+	sortColumn := cohortSortColumns["createdAt"]
+	if raw := parameters["sortBy"]; raw != "" {
+		column, ok := cohortSortColumns[raw]
+		if !ok {
+			return "", nil, fmt.Errorf("i2b2datasource: invalid sortBy %q", raw)
+		}
+		sortColumn = column
+	}
+	sortOrder := "DESC"
+	switch parameters["sortOrder"] {
+	case "", "desc":
+		sortOrder = "DESC"
+	case "asc":
+		sortOrder = "ASC"
+	default:
+		return "", nil, fmt.Errorf("i2b2datasource: invalid sortOrder %q", parameters["sortOrder"])
+	}
+
+	query := "SELECT name, patient_set_id, patient_count, created_at, updated_at, deleted_at FROM cohort"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s", sortColumn, sortOrder)
+	return query, args, nil
+}
+
+// GetCohortByNameHandler looks up a single cohort by name, matched
+// case-insensitively, instead of fetching every cohort via
+// GetCohortsHandler and filtering client-side. Returns a wrapped
+// sql.ErrNoRows (checkable with errors.Is) when no cohort matches.
+//
+// The cohort table has no per-user or per-project ownership column, so,
+// like GetCohortsHandler, this searches all cohorts rather than only the
+// requesting user's.
+//
+// Parameters:
+//   - name (string, required): the cohort name, matched case-insensitively.
+func (ds *I2b2DataSource) GetCohortByNameHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	name := parameters["name"]
+	if name == "" {
+		return nil, fmt.Errorf("i2b2datasource: GetCohortByName requires name")
+	}
+
+	var patientSetID string
+	err := ds.db.QueryRowContext(ctx,
+		`SELECT patient_set_id FROM cohort WHERE lower(name) = lower($1)`,
+		name,
+	).Scan(&patientSetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("i2b2datasource: cohort %q not found: %w", name, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: looking up cohort %q: %w", name, err)
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameGetCohortByName: map[string]interface{}{
+			"name":         name,
+			"patientSetID": patientSetID,
+		},
+	}, nil
+}
+
+// UpdateCohortHandler renames a cohort and/or repoints it at a different
+// i2b2 patient set. Like GetCohortByNameHandler, it has no per-user
+// ownership to scope against, so a name collision is checked across all
+// cohorts rather than only the requesting user's.
+//
+// The collision check, update, and read-back run inside a single
+// *sql.Tx, committing only on full success: without that, a concurrent
+// rename could slip between the check and the write and leave two
+// cohorts with colliding names.
+//
+// Repeating a call that only repoints the patient set, or that renames a
+// cohort to a case variant of its own name, succeeds again with the same
+// result. Repeating a rename to a different name does not: the first call
+// has already moved the row to newName, so the repeat fails the collision
+// check with "cohort ... already exists".
+//
+// Parameters:
+//   - name (string, required): the current cohort name identifying the row
+//     to update.
+//   - newName (string, optional): the name to rename the cohort to.
+//   - newPatientSetID (string, optional): the i2b2 patient set ID to
+//     associate with the cohort instead of its current one.
+//
+// At least one of newName or newPatientSetID must be set.
+func (ds *I2b2DataSource) UpdateCohortHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	name := parameters["name"]
+	newName := parameters["newName"]
+	newPatientSetID := parameters["newPatientSetID"]
+	if name == "" {
+		return nil, fmt.Errorf("i2b2datasource: UpdateCohort requires name")
+	}
+	if newName == "" && newPatientSetID == "" {
+		return nil, fmt.Errorf("i2b2datasource: UpdateCohort requires newName and/or newPatientSetID")
+	}
+	if newName == "" {
+		newName = name
+	}
+
+	// When repointing the cohort at a different patient set, refresh its
+	// stored patient_count to match, so GetCohortsHandler's patient-count
+	// filtering and sorting don't go stale.
+	var patientCountArg interface{}
+	if newPatientSetID != "" {
+		patientCount, err := ds.i2b2Client.GetPatientSetSize(ctx, newPatientSetID)
+		if err != nil {
+			return nil, fmt.Errorf("i2b2datasource: resolving patient count for cohort %q: %w", name, err)
+		}
+		patientCountArg = patientCount
+	}
+
+	var patientSetID string
+	var createdAt, updatedAt time.Time
+	err := ds.db.WithTx(ctx, func(tx *sql.Tx) error {
+		if !strings.EqualFold(newName, name) {
+			var existing string
+			err := tx.QueryRowContext(ctx,
+				`SELECT name FROM cohort WHERE lower(name) = lower($1)`,
+				newName,
+			).Scan(&existing)
+			if err == nil {
+				return fmt.Errorf("i2b2datasource: cohort %q already exists", newName)
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("i2b2datasource: checking for name collision on %q: %w", newName, err)
+			}
+		}
+
+		result, err := tx.ExecContext(ctx,
+			`UPDATE cohort SET name = $1, patient_set_id = COALESCE(NULLIF($2, ''), patient_set_id), patient_count = COALESCE($4, patient_count), updated_at = now() WHERE lower(name) = lower($3)`,
+			newName, newPatientSetID, name, patientCountArg,
+		)
+		if err != nil {
+			return fmt.Errorf("i2b2datasource: updating cohort %q: %w", name, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("i2b2datasource: updating cohort %q: %w", name, err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("i2b2datasource: cohort %q not found", name)
+		}
+
+		if err := tx.QueryRowContext(ctx,
+			`SELECT patient_set_id, created_at, updated_at FROM cohort WHERE lower(name) = lower($1)`,
+			newName,
+		).Scan(&patientSetID, &createdAt, &updatedAt); err != nil {
+			return fmt.Errorf("i2b2datasource: reading back updated cohort %q: %w", newName, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameUpdateCohort: map[string]interface{}{
+			"name":         newName,
+			"patientSetID": patientSetID,
+			"createdAt":    formatCohortTimestamp(createdAt),
+			"updatedAt":    formatCohortTimestamp(updatedAt),
+		},
+	}, nil
+}
+
+// DeleteCohortsHandler deletes a list of cohorts by name in a single
+// database transaction. A name with no matching, not-already-deleted
+// cohort is reported as a per-item failure rather than aborting the
+// transaction: the transaction only rolls back, deleting nothing, on a
+// genuine database error (e.g. a lost connection), never because some of
+// the requested names didn't exist.
+//
+// By default this is a soft delete: the row is kept with its deleted_at
+// timestamp set, excluded from GetCohortsHandler, and recoverable with
+// RestoreCohortsHandler until permanently removed with
+// PurgeCohortsHandler. Set i2b2.api.hard-delete-cohorts to permanently
+// remove the row immediately instead, for sites that don't want a
+// recoverable trash.
+//
+// Parameters:
+//   - names (string, required): comma-separated cohort names to delete.
+func (ds *I2b2DataSource) DeleteCohortsHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	raw := parameters["names"]
+	if raw == "" {
+		return nil, fmt.Errorf("i2b2datasource: DeleteCohorts requires names")
+	}
+	names := strings.Split(raw, ",")
+
+	query := `UPDATE cohort SET deleted_at = now() WHERE lower(name) = lower($1) AND deleted_at IS NULL`
+	if ds.hardDeleteCohorts {
+		query = `DELETE FROM cohort WHERE lower(name) = lower($1)`
+	}
+
+	var deletedCount int
+	failures := map[string]string{}
+	err := ds.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, name := range names {
+			result, err := tx.ExecContext(ctx, query, name)
+			if err != nil {
+				return fmt.Errorf("i2b2datasource: deleting cohort %q: %w", name, err)
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("i2b2datasource: deleting cohort %q: %w", name, err)
+			}
+			if rowsAffected == 0 {
+				failures[name] = "not found"
+				continue
+			}
+			deletedCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameDeleteCohorts: map[string]interface{}{
+			"deletedCount": deletedCount,
+			"failures":     failures,
+		},
+	}, nil
+}
+
+// RestoreCohortsHandler undoes DeleteCohortsHandler's soft delete for a
+// list of cohorts by name, in a single database transaction. A name that
+// isn't currently soft-deleted (never deleted, already restored, or
+// hard-deleted) is reported as a per-item failure rather than aborting the
+// transaction, the same way DeleteCohortsHandler reports an unmatched
+// name.
+//
+// Parameters:
+//   - names (string, required): comma-separated cohort names to restore.
+func (ds *I2b2DataSource) RestoreCohortsHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	raw := parameters["names"]
+	if raw == "" {
+		return nil, fmt.Errorf("i2b2datasource: RestoreCohorts requires names")
+	}
+	names := strings.Split(raw, ",")
+
+	var restoredCount int
+	failures := map[string]string{}
+	err := ds.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, name := range names {
+			result, err := tx.ExecContext(ctx,
+				`UPDATE cohort SET deleted_at = NULL WHERE lower(name) = lower($1) AND deleted_at IS NOT NULL`,
+				name,
+			)
+			if err != nil {
+				return fmt.Errorf("i2b2datasource: restoring cohort %q: %w", name, err)
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("i2b2datasource: restoring cohort %q: %w", name, err)
+			}
+			if rowsAffected == 0 {
+				failures[name] = "not found in trash"
+				continue
+			}
+			restoredCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameRestoreCohorts: map[string]interface{}{
+			"restoredCount": restoredCount,
+			"failures":      failures,
+		},
+	}, nil
+}
+
+// PurgeCohortsHandler permanently removes a list of soft-deleted cohorts by
+// name, in a single database transaction, bypassing the trash
+// DeleteCohortsHandler left them in. A name that isn't currently
+// soft-deleted is reported as a per-item failure rather than aborting the
+// transaction or purging an active cohort.
+//
+// Parameters:
+//   - names (string, required): comma-separated cohort names to purge.
+func (ds *I2b2DataSource) PurgeCohortsHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	raw := parameters["names"]
+	if raw == "" {
+		return nil, fmt.Errorf("i2b2datasource: PurgeCohorts requires names")
+	}
+	names := strings.Split(raw, ",")
+
+	var purgedCount int
+	failures := map[string]string{}
+	err := ds.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, name := range names {
+			result, err := tx.ExecContext(ctx,
+				`DELETE FROM cohort WHERE lower(name) = lower($1) AND deleted_at IS NOT NULL`,
+				name,
+			)
+			if err != nil {
+				return fmt.Errorf("i2b2datasource: purging cohort %q: %w", name, err)
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("i2b2datasource: purging cohort %q: %w", name, err)
+			}
+			if rowsAffected == 0 {
+				failures[name] = "not found in trash"
+				continue
+			}
+			purgedCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNamePurgeCohorts: map[string]interface{}{
+			"purgedCount": purgedCount,
+			"failures":    failures,
+		},
+	}, nil
+}
@@ -0,0 +1,87 @@
+package i2b2datasource
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDeriveCohortIdempotencyKeyDeterministic checks that the same
+// name/patientSetID pair always derives the same key, and that a
+// different pair derives a different one.
+func TestDeriveCohortIdempotencyKeyDeterministic(t *testing.T) {
+	a := deriveCohortIdempotencyKey("cohort-1", "12345")
+	b := deriveCohortIdempotencyKey("cohort-1", "12345")
+	if a != b {
+		t.Fatalf("deriveCohortIdempotencyKey() = %q, %q, want equal for the same inputs", a, b)
+	}
+
+	c := deriveCohortIdempotencyKey("cohort-1", "67890")
+	if a == c {
+		t.Fatalf("deriveCohortIdempotencyKey() = %q for both patient set IDs, want different keys", a)
+	}
+}
+
+func TestBuildGetCohortsQueryDefaults(t *testing.T) {
+	query, args, err := buildGetCohortsQuery(map[string]string{})
+	if err != nil {
+		t.Fatalf("buildGetCohortsQuery() error = %v", err)
+	}
+	if len(args) != 0 {
+		t.Fatalf("buildGetCohortsQuery() args = %v, want none", args)
+	}
+	if !strings.Contains(query, "deleted_at IS NULL") {
+		t.Fatalf("buildGetCohortsQuery() = %s, want it to exclude soft-deleted cohorts by default", query)
+	}
+	if !strings.Contains(query, "ORDER BY created_at DESC") {
+		t.Fatalf("buildGetCohortsQuery() = %s, want default sort of created_at DESC", query)
+	}
+}
+
+func TestBuildGetCohortsQueryFiltersAndSorts(t *testing.T) {
+	query, args, err := buildGetCohortsQuery(map[string]string{
+		"namePrefix":      "study-",
+		"minPatientCount": "10",
+		"maxPatientCount": "1000",
+		"sortBy":          "patientCount",
+		"sortOrder":       "asc",
+	})
+	if err != nil {
+		t.Fatalf("buildGetCohortsQuery() error = %v", err)
+	}
+	if len(args) != 3 {
+		t.Fatalf("buildGetCohortsQuery() args = %v, want 3 (namePrefix, min, max)", args)
+	}
+	if args[0] != "study-%" {
+		t.Fatalf("buildGetCohortsQuery() args[0] = %v, want %q", args[0], "study-%")
+	}
+	if !strings.Contains(query, "ORDER BY patient_count ASC") {
+		t.Fatalf("buildGetCohortsQuery() = %s, want sort by patient_count ASC", query)
+	}
+}
+
+func TestBuildGetCohortsQueryRejectsUnknownSortBy(t *testing.T) {
+	if _, _, err := buildGetCohortsQuery(map[string]string{"sortBy": "../etc/passwd"}); err == nil {
+		t.Fatal("buildGetCohortsQuery() error = nil, want error for an unrecognized sortBy")
+	}
+}
+
+func TestBuildGetCohortsQueryRejectsInvalidCreatedAfter(t *testing.T) {
+	if _, _, err := buildGetCohortsQuery(map[string]string{"createdAfter": "not-a-timestamp"}); err == nil {
+		t.Fatal("buildGetCohortsQuery() error = nil, want error for an invalid createdAfter")
+	}
+}
+
+// TestFormatCohortTimestampUTCRFC3339 checks that a non-UTC time.Time is
+// normalized to UTC and rendered in RFC3339, regardless of its original
+// location.
+func TestFormatCohortTimestampUTCRFC3339(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	ts := time.Date(2026, 1, 2, 10, 0, 0, 0, loc)
+
+	got := formatCohortTimestamp(ts)
+	want := "2026-01-02T15:00:00Z"
+	if got != want {
+		t.Fatalf("formatCohortTimestamp() = %q, want %q", got, want)
+	}
+}
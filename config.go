@@ -0,0 +1,88 @@
+package i2b2datasource
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultI2b2WaitTime is used when config does not set i2b2.api.wait-time.
+const defaultI2b2WaitTime = 60 * time.Second
+
+// defaultI2b2OntMaxElements is used when config does not set
+// i2b2.api.ont-max-elements.
+const defaultI2b2OntMaxElements = 200
+
+// parseOntMaxElements reads the i2b2.api.ont-max-elements config key,
+// validating it is positive, and falls back to defaultI2b2OntMaxElements
+// when it is unset.
+func parseOntMaxElements(config map[string]string) (int, error) {
+	raw, ok := config["i2b2.api.ont-max-elements"]
+	if !ok || raw == "" {
+		return defaultI2b2OntMaxElements, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("i2b2datasource: parsing i2b2.api.ont-max-elements: %w", err)
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("i2b2datasource: i2b2.api.ont-max-elements must be positive, got %d", value)
+	}
+	return value, nil
+}
+
+// defaultMaxObservationFacts is used when config does not set
+// i2b2.api.max-observation-facts.
+const defaultMaxObservationFacts = 5000
+
+// parseMaxObservationFacts reads the i2b2.api.max-observation-facts config
+// key, validating it is positive, and falls back to
+// defaultMaxObservationFacts when it is unset.
+func parseMaxObservationFacts(config map[string]string) (int, error) {
+	raw, ok := config["i2b2.api.max-observation-facts"]
+	if !ok || raw == "" {
+		return defaultMaxObservationFacts, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("i2b2datasource: parsing i2b2.api.max-observation-facts: %w", err)
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("i2b2datasource: i2b2.api.max-observation-facts must be positive, got %d", value)
+	}
+	return value, nil
+}
+
+// defaultAllowedFactFields is used when config does not set
+// i2b2.api.allowed-fact-fields.
+var defaultAllowedFactFields = []string{"patientID", "conceptCode", "startDate", "value", "units", "flag"}
+
+// parseAllowedFactFields reads the comma-separated
+// i2b2.api.allowed-fact-fields config key, falling back to
+// defaultAllowedFactFields when it is unset.
+func parseAllowedFactFields(config map[string]string) []string {
+	raw, ok := config["i2b2.api.allowed-fact-fields"]
+	if !ok || raw == "" {
+		return defaultAllowedFactFields
+	}
+	return strings.Split(raw, ",")
+}
+
+// parseCountSuppressionThreshold reads the
+// i2b2.api.count-suppression-threshold config key, validating it is
+// non-negative, and defaults to 0 (suppression disabled) when unset.
+func parseCountSuppressionThreshold(config map[string]string) (int, error) {
+	raw, ok := config["i2b2.api.count-suppression-threshold"]
+	if !ok || raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("i2b2datasource: parsing i2b2.api.count-suppression-threshold: %w", err)
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("i2b2datasource: i2b2.api.count-suppression-threshold must be non-negative, got %d", value)
+	}
+	return value, nil
+}
@@ -0,0 +1,21 @@
+package i2b2datasource
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// newCorrelationID generates a fresh correlation ID for a Query call whose
+// caller did not supply one via the correlationID parameter, so every
+// operation's log lines and outgoing i2b2 requests can still be tied
+// together even when the caller doesn't propagate its own ID.
+func newCorrelationID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		// crypto/rand.Read only fails if the platform's entropy source is
+		// unavailable, in which case nothing else in the process would work
+		// either; fall back to a fixed placeholder rather than panicking.
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
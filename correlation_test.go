@@ -0,0 +1,14 @@
+package i2b2datasource
+
+import "testing"
+
+func TestNewCorrelationIDDistinct(t *testing.T) {
+	a := newCorrelationID()
+	b := newCorrelationID()
+	if a == "" || b == "" {
+		t.Fatalf("newCorrelationID returned an empty ID")
+	}
+	if a == b {
+		t.Fatalf("newCorrelationID returned the same ID twice: %q", a)
+	}
+}
@@ -0,0 +1,196 @@
+package i2b2datasource
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GetData exports the result of a previously executed query (a patient set
+// or a breakdown, identified by parameters["resultID"]) as tabular data:
+// column headers plus a numeric matrix, read from the query_result_data
+// staging table written when the result was produced.
+//
+// query_result_data only stores numeric values (its "value" column is
+// DOUBLE PRECISION), so non-numeric columns are rejected at write time
+// rather than handled here; GetData itself only has numeric data to read.
+func (ds *I2b2DataSource) GetData(parameters map[string]string) ([]string, [][]float64, error) {
+	resultID := parameters["resultID"]
+	if resultID == "" {
+		return nil, nil, fmt.Errorf("i2b2datasource: GetData requires a resultID")
+	}
+
+	rows, err := ds.db.db.Query(
+		`SELECT row_num, col_name, value FROM query_result_data WHERE result_id = $1 ORDER BY row_num`,
+		resultID,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("i2b2datasource: GetData: %w", err)
+	}
+	defer rows.Close()
+
+	columnIndex := map[string]int{}
+	var headers []string
+	valuesByRow := map[int]map[string]float64{}
+	var rowNums []int
+
+	for rows.Next() {
+		var rowNum int
+		var colName string
+		var value float64
+		if err := rows.Scan(&rowNum, &colName, &value); err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: GetData: scanning row: %w", err)
+		}
+		if _, ok := columnIndex[colName]; !ok {
+			columnIndex[colName] = len(headers)
+			headers = append(headers, colName)
+		}
+		if _, ok := valuesByRow[rowNum]; !ok {
+			valuesByRow[rowNum] = map[string]float64{}
+			rowNums = append(rowNums, rowNum)
+		}
+		valuesByRow[rowNum][colName] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("i2b2datasource: GetData: %w", err)
+	}
+	if len(rowNums) == 0 {
+		return nil, nil, fmt.Errorf("i2b2datasource: GetData: unknown result identifier %q", resultID)
+	}
+
+	sort.Ints(rowNums)
+	matrix := make([][]float64, len(rowNums))
+	for i, rowNum := range rowNums {
+		row := make([]float64, len(headers))
+		for colName, value := range valuesByRow[rowNum] {
+			row[columnIndex[colName]] = value
+		}
+		matrix[i] = row
+	}
+
+	return headers, matrix, nil
+}
+
+// loadDataPatientIDColumn is the column name LoadData expects to identify
+// the patient for each row; every other column is a numeric observation
+// written to the cohort_data staging table.
+const loadDataPatientIDColumn = "patientID"
+
+// LoadData ingests an externally prepared cohort into i2b2 and Postgres so
+// it can be referenced by later queries.
+//
+// Parameters:
+//   - name (string, required): the cohort name to register.
+//   - columns (string, required): a comma-separated schema for data's rows;
+//     the first column must be loadDataPatientIDColumn, every other column
+//     is a numeric observation.
+//
+// data must be [][]string or [][]float64; any other type is a clear error.
+func (ds *I2b2DataSource) LoadData(parameters map[string]string, data interface{}) error {
+	name := parameters["name"]
+	if name == "" {
+		return fmt.Errorf("i2b2datasource: LoadData requires a name")
+	}
+	columns := strings.Split(parameters["columns"], ",")
+	if len(columns) == 0 || columns[0] != loadDataPatientIDColumn {
+		return fmt.Errorf("i2b2datasource: LoadData requires the first column to be %q, got %v", loadDataPatientIDColumn, columns)
+	}
+
+	ctx := context.Background()
+	var patientIDs []string
+	err := ds.db.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		switch rows := data.(type) {
+		case [][]string:
+			patientIDs, err = writeCohortDataStringRows(ctx, tx, name, columns, rows)
+		case [][]float64:
+			patientIDs, err = writeCohortDataFloatRows(ctx, tx, name, columns, rows)
+		default:
+			return fmt.Errorf("i2b2datasource: LoadData: unsupported data type %T, want [][]string or [][]float64", data)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	patientSetID, err := ds.i2b2Client.CreatePatientSetFromIDs(ctx, patientIDs)
+	if err != nil {
+		return fmt.Errorf("i2b2datasource: LoadData: creating patient set: %w", err)
+	}
+
+	if _, err := ds.db.ExecContext(ctx, `INSERT INTO cohort (name, patient_set_id) VALUES ($1, $2)`, name, patientSetID); err != nil {
+		return fmt.Errorf("i2b2datasource: LoadData: recording cohort %q: %w", name, err)
+	}
+	return nil
+}
+
+// writeCohortDataStringRows validates rows against columns and writes each
+// non-patient-ID value into the cohort_data staging table within tx, parsing
+// it as a float64.
+func writeCohortDataStringRows(ctx context.Context, tx *sql.Tx, name string, columns []string, rows [][]string) ([]string, error) {
+	patientIDs := make([]string, 0, len(rows))
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("i2b2datasource: LoadData: row %d has %d values, want %d", i, len(row), len(columns))
+		}
+		patientIDs = append(patientIDs, row[0])
+		for j, col := range columns[1:] {
+			value, err := strconv.ParseFloat(row[j+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("i2b2datasource: LoadData: row %d column %q: %w", i, col, err)
+			}
+			if err := writeCohortDataValue(ctx, tx, name, row[0], col, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return patientIDs, nil
+}
+
+// writeCohortDataFloatRows validates rows against columns and writes each
+// non-patient-ID value into the cohort_data staging table within tx. The
+// patient ID column is itself numeric in this form and is formatted back to
+// a string.
+func writeCohortDataFloatRows(ctx context.Context, tx *sql.Tx, name string, columns []string, rows [][]float64) ([]string, error) {
+	patientIDs := make([]string, 0, len(rows))
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("i2b2datasource: LoadData: row %d has %d values, want %d", i, len(row), len(columns))
+		}
+		patientID := strconv.FormatFloat(row[0], 'f', -1, 64)
+		patientIDs = append(patientIDs, patientID)
+		for j, col := range columns[1:] {
+			if err := writeCohortDataValue(ctx, tx, name, patientID, col, row[j+1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return patientIDs, nil
+}
+
+// writeCohortDataValue writes a single cohort_data row within tx, so that a
+// failure partway through a batch (see writeCohortDataStringRows/
+// writeCohortDataFloatRows) rolls back every row already written for this
+// LoadData call instead of leaving a cohort with only some of its rows
+// persisted.
+func writeCohortDataValue(ctx context.Context, tx *sql.Tx, cohortName, patientID, colName string, value float64) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO cohort_data (cohort_name, patient_id, col_name, value) VALUES ($1, $2, $3, $4)`,
+		cohortName, patientID, colName, value,
+	)
+	if err != nil {
+		return fmt.Errorf("i2b2datasource: LoadData: writing patient %q column %q: %w", patientID, colName, err)
+	}
+	return nil
+}
+
+// Data returns the data source's current dataset description.
+//
+// TODO: not implemented yet.
+func (ds *I2b2DataSource) Data(parameters map[string]string) (interface{}, error) {
+	return nil, fmt.Errorf("i2b2datasource: Data is not implemented")
+}
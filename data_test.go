@@ -0,0 +1,116 @@
+package i2b2datasource
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// failAfterNDriver is a minimal database/sql/driver fake that lets a test
+// inject a failure partway through a batch of writes, so the test can
+// assert that a partially-failed transaction rolls back rather than
+// leaving some of its statements committed.
+//
+// It only implements enough of the driver interfaces to support the
+// Exec-only statements LoadData issues (INSERT ... with no result rows).
+type failAfterNDriver struct {
+	mu      sync.Mutex
+	execs   int
+	failOn  int // the execs-th Exec (1-indexed) fails; 0 means never fail
+	pending []string
+	commits []string // statements from every committed transaction
+}
+
+func (d *failAfterNDriver) Open(name string) (driver.Conn, error) {
+	return &failAfterNConn{driver: d}, nil
+}
+
+type failAfterNConn struct {
+	driver *failAfterNDriver
+}
+
+func (c *failAfterNConn) Prepare(query string) (driver.Stmt, error) {
+	return &failAfterNStmt{conn: c, query: query}, nil
+}
+func (c *failAfterNConn) Close() error              { return nil }
+func (c *failAfterNConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *failAfterNConn) Commit() error {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	c.driver.commits = append(c.driver.commits, c.driver.pending...)
+	c.driver.pending = nil
+	return nil
+}
+func (c *failAfterNConn) Rollback() error {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	c.driver.pending = nil
+	return nil
+}
+
+type failAfterNStmt struct {
+	conn  *failAfterNConn
+	query string
+}
+
+func (s *failAfterNStmt) Close() error  { return nil }
+func (s *failAfterNStmt) NumInput() int { return -1 }
+func (s *failAfterNStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.driver.mu.Lock()
+	defer s.conn.driver.mu.Unlock()
+	s.conn.driver.execs++
+	if s.conn.driver.failOn != 0 && s.conn.driver.execs == s.conn.driver.failOn {
+		return nil, fmt.Errorf("failAfterNDriver: injected failure on exec %d", s.conn.driver.execs)
+	}
+	s.conn.driver.pending = append(s.conn.driver.pending, s.query)
+	return driver.ResultNoRows, nil
+}
+func (s *failAfterNStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("failAfterNDriver: Query not supported")
+}
+
+// newFailAfterNDataSource returns an I2b2DataSource backed by a
+// failAfterNDriver whose failOn-th Exec call fails, so writeCohortDataValue
+// calls after that point never happen.
+func newFailAfterNDataSource(t *testing.T, failOn int) (*I2b2DataSource, *failAfterNDriver) {
+	t.Helper()
+	drv := &failAfterNDriver{failOn: failOn}
+	driverName := fmt.Sprintf("failAfterN-%p", drv)
+	sql.Register(driverName, drv)
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		t.Fatalf("sql.Open() = %v", err)
+	}
+	ds := &I2b2DataSource{db: &PostgresDatabase{db: db, metrics: noopMetrics{}}}
+	return ds, drv
+}
+
+// TestLoadDataRollsBackOnMidBatchFailure checks that a failure partway
+// through writing a cohort's rows rolls back every row already written in
+// that call, rather than leaving a cohort with only some of its data
+// persisted.
+func TestLoadDataRollsBackOnMidBatchFailure(t *testing.T) {
+	ds, drv := newFailAfterNDataSource(t, 2)
+
+	rows := [][]string{
+		{"p1", "1.0"},
+		{"p2", "2.0"},
+		{"p3", "3.0"},
+	}
+	err := ds.db.WithTx(context.Background(), func(tx *sql.Tx) error {
+		_, err := writeCohortDataStringRows(context.Background(), tx, "cohort-a", []string{loadDataPatientIDColumn, "value"}, rows)
+		return err
+	})
+	if err == nil {
+		t.Fatalf("WithTx() = nil, want the injected failure on the second row's write")
+	}
+
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+	if len(drv.commits) != 0 {
+		t.Fatalf("committed statements = %v, want none: the failed transaction must not persist the first row either", drv.commits)
+	}
+}
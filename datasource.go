@@ -0,0 +1,549 @@
+package i2b2datasource
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ldsec/GeCo/gecosdk"
+	"github.com/ldsec/geco-i2b2-data-source/i2b2client"
+	"github.com/sirupsen/logrus"
+)
+
+// I2b2DataSource is a GeCo data source plugin that executes queries against
+// an i2b2 hive and stores cohorts in a Postgres database.
+type I2b2DataSource struct {
+	gecosdk.DataSourceModel
+
+	i2b2Client *i2b2client.Client
+	db         *PostgresDatabase
+
+	// allowedDemographicFields restricts which patient_dimension fields
+	// ExploreQueryHandler may surface as demographics, so a misconfigured
+	// hive schema can't leak sensitive columns to callers.
+	allowedDemographicFields []string
+
+	// ontCache caches SearchOntology results; nil when
+	// i2b2.api.ont-cache-ttl is unset, meaning caching is disabled.
+	ontCache *ontCache
+
+	// exploreCache caches ExploreQuery results keyed by normalized query
+	// definition; nil when i2b2.api.explore-cache-ttl is unset, meaning
+	// caching is disabled.
+	exploreCache *exploreCache
+
+	// ontMaxElements caps the number of terms returned by a single ONT cell
+	// search (see parseOntMaxElements).
+	ontMaxElements int
+
+	// countSuppressionThreshold is the minimum count ExploreQueryHandler
+	// will report exactly; counts (and breakdown entry counts) below it are
+	// replaced with a suppressed indicator. 0 disables suppression. See
+	// parseCountSuppressionThreshold.
+	countSuppressionThreshold int
+
+	// maxObservationFacts caps the number of facts a single
+	// GetObservationFactsHandler page returns. See
+	// parseMaxObservationFacts.
+	maxObservationFacts int
+
+	// allowedFactFields restricts which ObservationFact fields
+	// GetObservationFactsHandler may include in its result, so a
+	// misconfigured hive schema can't leak PHI-bearing fields (patientID,
+	// startDate) to callers that don't need them. See
+	// parseAllowedFactFields.
+	allowedFactFields []string
+
+	// hardDeleteCohorts makes DeleteCohortsHandler permanently remove rows
+	// instead of the default soft delete. See i2b2.api.hard-delete-cohorts.
+	hardDeleteCohorts bool
+
+	// metrics receives operation and DB latency observations; defaults to
+	// noopMetrics. See SetMetrics.
+	metrics Metrics
+
+	// lastOperation records the most recently dispatched Operation, mainly so
+	// a pointer-receiver regression (a previous bug had Query on a value
+	// receiver, silently discarding state) is easy to catch in tests.
+	lastOperation Operation
+
+	// closeMu guards closed. Query holds it for reading for the duration of
+	// the operation it runs, so Close (which takes it for writing) blocks
+	// until every in-flight Query call has returned before releasing the
+	// database connection pool.
+	closeMu sync.RWMutex
+
+	// closed is set once Close has run, so subsequent Query calls are
+	// rejected with ErrClosed instead of racing the database handle being
+	// closed underneath them.
+	closed bool
+}
+
+var _ gecosdk.DataSource = &I2b2DataSource{}
+
+// NewI2b2DataSource builds an I2b2DataSource from a flat GeCo configuration
+// map. Recognized keys:
+//   - i2b2.api.url, i2b2.api.domain, i2b2.api.username, i2b2.api.password,
+//     i2b2.api.project: hive connection info.
+//   - i2b2.api.wait-time: i2b2 result wait time, parsed with
+//     time.ParseDuration (e.g. "60s"); unset defaults to
+//     defaultI2b2WaitTime, a present-but-invalid value is an error. This is
+//     sent to the hive as the synchronous result wait time; it does not
+//     bound our own HTTP client, see i2b2.api.http-timeout.
+//   - i2b2.api.http-timeout: bounds the client-side HTTP round trip
+//     (including retries), parsed with time.ParseDuration; unset falls back
+//     to i2b2.api.wait-time, i.e. a single combined timeout.
+//   - i2b2.api.max-retries, i2b2.api.retry-base-delay: retry behavior for
+//     transient i2b2 HTTP failures.
+//   - i2b2.api.circuit-breaker-threshold, i2b2.api.circuit-breaker-cooldown:
+//     when threshold is set to a positive integer, the i2b2 client
+//     short-circuits requests with i2b2client.ErrCircuitOpen after that
+//     many consecutive failures instead of retrying and timing out against
+//     a hive that is down, for circuit-breaker-cooldown (parsed with
+//     time.ParseDuration) before probing again; unset (or non-positive)
+//     disables the breaker. See i2b2client.WithCircuitBreaker and
+//     HealthStatus.I2b2CircuitBreaker.
+//   - i2b2.api.max-concurrent-requests: bounds how many i2b2 HTTP requests
+//     the client has in flight at once, across all operations, via a
+//     weighted semaphore; unset (or non-positive) leaves requests
+//     unbounded. Useful with the batch and breakdown features, which can
+//     otherwise fan out many concurrent requests at once.
+//   - i2b2.api.tls.client-cert, i2b2.api.tls.client-key, i2b2.api.tls.ca-file,
+//     i2b2.api.tls.insecure-skip-verify: mutual-TLS settings for hives behind
+//     a client-certificate-authenticated proxy.
+//   - i2b2.api.proxy-url: forward proxy to route i2b2 HTTP traffic through;
+//     when unset, HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment variables are
+//     honored instead.
+//   - i2b2.api.gzip-requests: when "true", gzip-compress outgoing request
+//     bodies; gzipped responses are always decompressed regardless.
+//   - i2b2.api.dump-messages: when "true", log the full outgoing request
+//     XML (security password redacted) and raw response body at debug
+//     level; see i2b2client.WithDumpMessages.
+//   - i2b2.api.user-agent: overrides the User-Agent sent with every i2b2
+//     HTTP request; unset defaults to a value derived from
+//     i2b2.api.application-version, so hive access logs can still
+//     distinguish this data source's traffic.
+//   - i2b2.api.trace-header-name: overrides the HTTP header a caller's
+//     trace/correlation ID (see i2b2client.ContextWithTraceID) is sent
+//     under; unset defaults to i2b2client.DefaultTraceHeaderName.
+//   - i2b2.api.poll-interval-min, i2b2.api.poll-interval-max: parsed with
+//     time.ParseDuration, clamp the hive-advertised polling interval a
+//     long-running CRC query is polled at; either may be set alone. A
+//     clamp is logged at debug level. See i2b2client.WithPollIntervalBounds.
+//   - i2b2.api.max-poll-count: caps the number of polling attempts for a
+//     long-running CRC query, as a safety valve independent of
+//     i2b2.api.wait-time; unset (or non-positive) leaves it unbounded. See
+//     i2b2client.WithMaxPollCount.
+//   - i2b2.api.max-response-bytes: caps how many bytes of an i2b2 response
+//     body are buffered before unmarshalling; unset (or non-positive)
+//     leaves it unbounded. See i2b2client.WithMaxResponseBytes.
+//   - i2b2.api.allowed-demographic-fields: comma-separated subset of
+//     age/sex/vitalStatus that ExploreQueryHandler may return as
+//     demographics; defaults to all three.
+//   - i2b2.api.ont-cache-ttl: when set, SearchOntology results are cached
+//     in memory (bounded LRU) for this long, parsed with
+//     time.ParseDuration; unset disables caching.
+//   - i2b2.api.ont-max-elements: caps the number of terms returned by a
+//     single ONT cell search; must be positive; defaults to
+//     defaultI2b2OntMaxElements when unset.
+//   - i2b2.api.explore-cache-ttl: when set, ExploreQuery results are
+//     cached in memory (bounded LRU), keyed by a panel/item-order
+//     insensitive normalization of the query definition plus the project
+//     and breakdowns requested, for this long, parsed with
+//     time.ParseDuration; unset disables caching. A caller can force a
+//     fresh CRC run regardless via the forceRefresh parameter.
+//   - i2b2.api.count-suppression-threshold: when set to a positive integer,
+//     ExploreQueryHandler reports any count or breakdown entry count below
+//     it as suppressed instead of its exact value; unset (or 0) disables
+//     suppression.
+//   - i2b2.api.max-observation-facts: caps the number of facts a single
+//     GetObservationFactsHandler page returns; must be positive; defaults
+//     to defaultMaxObservationFacts when unset.
+//   - i2b2.api.allowed-fact-fields: comma-separated subset of
+//     patientID/conceptCode/startDate/value/units/flag that
+//     GetObservationFactsHandler may include in its result; defaults to
+//     all of them.
+//   - i2b2.api.hard-delete-cohorts: when "true", DeleteCohortsHandler
+//     permanently removes rows instead of its default soft delete, for
+//     sites that don't want a recoverable trash.
+//   - i2b2.api.version-compatible, i2b2.api.application-version: override
+//     the i2b2_version_compatible and receiving application version sent
+//     with every request; unset keeps i2b2client's current hive defaults.
+//   - i2b2.api.datetime-format, i2b2.api.timezone: override the
+//     time.Format layout and IANA zone name (parsed with time.LoadLocation,
+//     e.g. "Europe/Zurich") used to render the datetime_of_message field
+//     sent with every request; unset defaults to RFC3339 in UTC.
+//   - db.host, db.port, db.user, db.password, db.name: Postgres cohort
+//     store connection info.
+//   - db.max-open-conns, db.max-idle-conns, db.conn-max-lifetime: Postgres
+//     connection pool sizing; see NewPostgresDatabase for defaults.
+//   - db.ssl-mode, db.ssl-root-cert, db.ssl-cert, db.ssl-key: Postgres TLS
+//     settings; see buildPostgresDSN.
+func NewI2b2DataSource(config map[string]string) (*I2b2DataSource, error) {
+	if _, warnings := ConfigFromMap(config); len(warnings) > 0 {
+		for _, warning := range warnings {
+			logger.Warn(warning)
+		}
+	}
+
+	i2b2Client, db, err := newHandlers(config)
+	if err != nil {
+		return nil, err
+	}
+	ontCacheTTL, err := parseOntCacheTTL(config)
+	if err != nil {
+		return nil, err
+	}
+	var cache *ontCache
+	if ontCacheTTL > 0 {
+		cache = newOntCache(ontCacheTTL, defaultOntCacheSize)
+	}
+	ontMaxElements, err := parseOntMaxElements(config)
+	if err != nil {
+		return nil, err
+	}
+	exploreCacheTTL, err := parseExploreCacheTTL(config)
+	if err != nil {
+		return nil, err
+	}
+	var exploreCache *exploreCache
+	if exploreCacheTTL > 0 {
+		exploreCache = newExploreCache(exploreCacheTTL, defaultExploreCacheSize)
+	}
+	countSuppressionThreshold, err := parseCountSuppressionThreshold(config)
+	if err != nil {
+		return nil, err
+	}
+	maxObservationFacts, err := parseMaxObservationFacts(config)
+	if err != nil {
+		return nil, err
+	}
+	return &I2b2DataSource{
+		i2b2Client:                i2b2Client,
+		db:                        db,
+		allowedDemographicFields:  parseAllowedDemographicFields(config),
+		ontCache:                  cache,
+		exploreCache:              exploreCache,
+		ontMaxElements:            ontMaxElements,
+		countSuppressionThreshold: countSuppressionThreshold,
+		maxObservationFacts:       maxObservationFacts,
+		allowedFactFields:         parseAllowedFactFields(config),
+		hardDeleteCohorts:         config["i2b2.api.hard-delete-cohorts"] == "true",
+		metrics:                   noopMetrics{},
+	}, nil
+}
+
+// defaultAllowedDemographicFields is used when config does not set
+// i2b2.api.allowed-demographic-fields.
+var defaultAllowedDemographicFields = []string{"age", "sex", "vitalStatus"}
+
+// parseAllowedDemographicFields reads the comma-separated
+// i2b2.api.allowed-demographic-fields config key, falling back to
+// defaultAllowedDemographicFields when it is unset.
+func parseAllowedDemographicFields(config map[string]string) []string {
+	raw, ok := config["i2b2.api.allowed-demographic-fields"]
+	if !ok || raw == "" {
+		return defaultAllowedDemographicFields
+	}
+	return strings.Split(raw, ",")
+}
+
+// newHandlers builds the i2b2 client and Postgres handle from config. It is
+// shared by NewI2b2DataSource and FromModel so a restored data source is
+// configured identically to a freshly created one.
+func newHandlers(config map[string]string) (*i2b2client.Client, *PostgresDatabase, error) {
+	waitTime := defaultI2b2WaitTime
+	if raw := config["i2b2.api.wait-time"]; raw != "" {
+		var err error
+		waitTime, err = time.ParseDuration(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.wait-time: %w", err)
+		}
+	}
+
+	var timeZone *time.Location
+	if raw := config["i2b2.api.timezone"]; raw != "" {
+		var err error
+		timeZone, err = time.LoadLocation(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.timezone: %w", err)
+		}
+	}
+
+	conn := i2b2client.ConnectionInfo{
+		HiveURL:            config["i2b2.api.url"],
+		Domain:             config["i2b2.api.domain"],
+		Username:           config["i2b2.api.username"],
+		Password:           config["i2b2.api.password"],
+		ProjectID:          config["i2b2.api.project"],
+		WaitTime:           waitTime,
+		VersionCompatible:  config["i2b2.api.version-compatible"],
+		ApplicationVersion: config["i2b2.api.application-version"],
+		DateTimeFormat:     config["i2b2.api.datetime-format"],
+		TimeZone:           timeZone,
+	}
+	if err := conn.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("i2b2datasource: invalid i2b2 connection config: %w", err)
+	}
+
+	var clientOpts []i2b2client.ClientOption
+
+	if certFile, keyFile, caFile := config["i2b2.api.tls.client-cert"], config["i2b2.api.tls.client-key"], config["i2b2.api.tls.ca-file"]; certFile != "" || keyFile != "" || caFile != "" {
+		tlsConfig, err := i2b2client.BuildTLSConfig(i2b2client.TLSConfig{
+			ClientCertFile:     certFile,
+			ClientKeyFile:      keyFile,
+			CAFile:             caFile,
+			InsecureSkipVerify: config["i2b2.api.tls.insecure-skip-verify"] == "true",
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: %w", err)
+		}
+		clientOpts = append(clientOpts, i2b2client.WithTLSConfig(tlsConfig))
+	}
+
+	if raw := config["i2b2.api.proxy-url"]; raw != "" {
+		proxyURL, err := url.Parse(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.proxy-url: %w", err)
+		}
+		clientOpts = append(clientOpts, i2b2client.WithProxyURL(proxyURL))
+	}
+
+	if config["i2b2.api.gzip-requests"] == "true" {
+		clientOpts = append(clientOpts, i2b2client.WithGzipRequests(true))
+	}
+
+	if config["i2b2.api.dump-messages"] == "true" {
+		clientOpts = append(clientOpts, i2b2client.WithDumpMessages(true))
+	}
+
+	if raw := config["i2b2.api.user-agent"]; raw != "" {
+		clientOpts = append(clientOpts, i2b2client.WithUserAgent(raw))
+	}
+
+	if raw := config["i2b2.api.trace-header-name"]; raw != "" {
+		clientOpts = append(clientOpts, i2b2client.WithTraceHeaderName(raw))
+	}
+
+	if minRaw, maxRaw := config["i2b2.api.poll-interval-min"], config["i2b2.api.poll-interval-max"]; minRaw != "" || maxRaw != "" {
+		var minInterval, maxInterval time.Duration
+		if minRaw != "" {
+			minInterval, err = time.ParseDuration(minRaw)
+			if err != nil {
+				return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.poll-interval-min: %w", err)
+			}
+		}
+		if maxRaw != "" {
+			maxInterval, err = time.ParseDuration(maxRaw)
+			if err != nil {
+				return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.poll-interval-max: %w", err)
+			}
+		}
+		clientOpts = append(clientOpts, i2b2client.WithPollIntervalBounds(minInterval, maxInterval))
+	}
+
+	if raw, ok := config["i2b2.api.max-poll-count"]; ok && raw != "" {
+		maxPollCount, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.max-poll-count: %w", err)
+		}
+		clientOpts = append(clientOpts, i2b2client.WithMaxPollCount(maxPollCount))
+	}
+
+	if raw, ok := config["i2b2.api.max-response-bytes"]; ok && raw != "" {
+		maxResponseBytes, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.max-response-bytes: %w", err)
+		}
+		clientOpts = append(clientOpts, i2b2client.WithMaxResponseBytes(maxResponseBytes))
+	}
+
+	if raw := config["i2b2.api.http-timeout"]; raw != "" {
+		httpTimeout, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.http-timeout: %w", err)
+		}
+		clientOpts = append(clientOpts, i2b2client.WithHTTPTimeout(httpTimeout))
+	}
+
+	if raw, ok := config["i2b2.api.max-concurrent-requests"]; ok && raw != "" {
+		maxConcurrent, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.max-concurrent-requests: %w", err)
+		}
+		clientOpts = append(clientOpts, i2b2client.WithMaxConcurrentRequests(maxConcurrent))
+	}
+
+	if raw, ok := config["i2b2.api.max-retries"]; ok && raw != "" {
+		maxRetries, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.max-retries: %w", err)
+		}
+		retryBaseDelay, err := time.ParseDuration(config["i2b2.api.retry-base-delay"])
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.retry-base-delay: %w", err)
+		}
+		clientOpts = append(clientOpts, i2b2client.WithRetry(maxRetries, retryBaseDelay))
+	}
+
+	if raw, ok := config["i2b2.api.circuit-breaker-threshold"]; ok && raw != "" {
+		threshold, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.circuit-breaker-threshold: %w", err)
+		}
+		cooldown, err := time.ParseDuration(config["i2b2.api.circuit-breaker-cooldown"])
+		if err != nil {
+			return nil, nil, fmt.Errorf("i2b2datasource: parsing i2b2.api.circuit-breaker-cooldown: %w", err)
+		}
+		clientOpts = append(clientOpts, i2b2client.WithCircuitBreaker(threshold, cooldown))
+	}
+
+	db, err := NewPostgresDatabase(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return i2b2client.NewClient(conn, clientOpts...), db, nil
+}
+
+// Query dispatches a GeCo query to the handler for the requested operation,
+// propagating ctx so a hung i2b2 hive or slow database can be cancelled by
+// the caller.
+//
+// A correlation ID ties together every log line this call produces,
+// including those logged deep inside i2b2client, and is echoed in the i2b2
+// message control id of every request it sends, so a single user action can
+// be traced across the shared logger and the hive's own logs. Callers that
+// already track their own request ID can pass it as the correlationID
+// parameter; otherwise one is generated. Every call that passes the
+// ErrClosed check and the outputDataObjectsSharedIDs validation below logs
+// a single "operation completed"/"operation failed" line carrying operation,
+// userID, correlationID, and duration as structured fields (plus error on
+// failure), so logs can be filtered/aggregated by any of them instead of
+// parsed out of an interpolated message. duration also breaks down into
+// i2b2Duration and dbDuration, the time the dispatched handler spent
+// talking to the hive versus the database respectively, so a slow
+// operation's bottleneck is visible without instrumenting each handler
+// individually.
+//
+// outputDataObjectsSharedIDs is validated before dispatch: it must carry
+// an entry for every OutputDataObjectNamesFor(operation) name, or Query
+// returns a clear error naming the missing one instead of letting it
+// surface later as a nil-map panic or a silently dropped output inside
+// the handler.
+func (ds *I2b2DataSource) Query(ctx context.Context, userID string, operation Operation, parameters map[string]string, outputDataObjectsSharedIDs map[OutputDataObjectName]string) (map[OutputDataObjectName]interface{}, error) {
+	ds.closeMu.RLock()
+	defer ds.closeMu.RUnlock()
+	if ds.closed {
+		return nil, ErrClosed
+	}
+	if err := validateOutputDataObjectsSharedIDs(operation, outputDataObjectsSharedIDs); err != nil {
+		return nil, err
+	}
+
+	ds.lastOperation = operation
+
+	correlationID := parameters["correlationID"]
+	if correlationID == "" {
+		correlationID = newCorrelationID()
+	}
+	entry := logger.WithFields(logrus.Fields{
+		"correlationID": correlationID,
+		"userID":        userID,
+		"operation":     operation,
+	})
+	ctx = i2b2client.ContextWithTraceID(ctx, correlationID)
+	ctx = contextWithLogger(ctx, entry)
+	var i2b2Duration i2b2client.DurationAccumulator
+	ctx = i2b2client.ContextWithDurationAccumulator(ctx, &i2b2Duration)
+	timers := &operationTimers{}
+	ctx = contextWithOperationTimers(ctx, timers)
+
+	start := time.Now()
+	result, err := ds.dispatch(ctx, operation, parameters)
+	duration := time.Since(start)
+	ds.metrics.ObserveOperation(operation, err == nil)
+
+	fields := logrus.Fields{
+		"duration":     duration,
+		"i2b2Duration": i2b2Duration.Duration(),
+		"dbDuration":   timers.dbDuration(),
+	}
+	if err != nil {
+		entry.WithFields(fields).WithError(err).Warn("operation failed")
+	} else {
+		entry.WithFields(fields).Info("operation completed")
+	}
+	return result, err
+}
+
+func (ds *I2b2DataSource) dispatch(ctx context.Context, operation Operation, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	switch operation {
+	case OperationExploreQuery:
+		return ds.ExploreQueryHandler(ctx, parameters)
+	case OperationCountQuery:
+		return ds.CountQueryHandler(ctx, parameters)
+	case OperationPreviewExploreQuery:
+		return ds.PreviewExploreQueryHandler(ctx, parameters)
+	case OperationSearchOntology:
+		return ds.SearchOntologyHandler(ctx, parameters)
+	case OperationSearchModifier:
+		return ds.SearchModifierHandler(ctx, parameters)
+	case OperationSearchModifierByName:
+		return ds.SearchModifierByNameHandler(ctx, parameters)
+	case OperationBatchSearchConcept:
+		return ds.BatchSearchConceptHandler(ctx, parameters)
+	case OperationResolveConcepts:
+		return ds.ResolveConceptsHandler(ctx, parameters)
+	case OperationGetConceptMetadata:
+		return ds.GetConceptMetadataHandler(ctx, parameters)
+	case OperationAddCohort:
+		return ds.AddCohortHandler(ctx, parameters)
+	case OperationGetCohorts:
+		return ds.GetCohortsHandler(ctx, parameters)
+	case OperationGetCohortByName:
+		return ds.GetCohortByNameHandler(ctx, parameters)
+	case OperationUpdateCohort:
+		return ds.UpdateCohortHandler(ctx, parameters)
+	case OperationDeleteCohorts:
+		return ds.DeleteCohortsHandler(ctx, parameters)
+	case OperationRestoreCohorts:
+		return ds.RestoreCohortsHandler(ctx, parameters)
+	case OperationPurgeCohorts:
+		return ds.PurgeCohortsHandler(ctx, parameters)
+	case OperationListProjects:
+		return ds.ListProjectsHandler(ctx, parameters)
+	case OperationListWorkplaceFolders:
+		return ds.ListWorkplaceFoldersHandler(ctx, parameters)
+	case OperationListQueryHistory:
+		return ds.ListQueryHistoryHandler(ctx, parameters)
+	case OperationGetQueryResultInstances:
+		return ds.GetQueryResultInstancesHandler(ctx, parameters)
+	case OperationRenameQueryMaster:
+		return ds.RenameQueryMasterHandler(ctx, parameters)
+	case OperationGetQueryStatus:
+		return ds.GetQueryStatusHandler(ctx, parameters)
+	case OperationCancelQuery:
+		return ds.CancelQueryHandler(ctx, parameters)
+	case OperationGetObservationFacts:
+		return ds.GetObservationFactsHandler(ctx, parameters)
+	case OperationGetSchemes:
+		return ds.GetSchemesHandler(ctx, parameters)
+	case OperationSearchConceptByCode:
+		return ds.SearchConceptByCodeHandler(ctx, parameters)
+	case OperationLoadData:
+		return nil, fmt.Errorf("i2b2datasource: operation %s is not implemented", operation)
+	case OperationGetData:
+		return nil, fmt.Errorf("i2b2datasource: operation %s is not implemented", operation)
+	case OperationSurvivalQuery:
+		return nil, fmt.Errorf("i2b2datasource: operation %s is not implemented", operation)
+	case OperationCrossBreakdownQuery:
+		return nil, fmt.Errorf("i2b2datasource: operation %s is not implemented", operation)
+	default:
+		return nil, fmt.Errorf("i2b2datasource: unknown operation %s", operation)
+	}
+}
@@ -0,0 +1,38 @@
+package i2b2datasource
+
+import (
+	"context"
+	"testing"
+)
+
+// TestQueryMutatesReceiver guards against a past regression where Query was
+// declared on a value receiver, so any state it set was discarded once the
+// call returned.
+func TestQueryMutatesReceiver(t *testing.T) {
+	ds := &I2b2DataSource{metrics: noopMetrics{}}
+
+	_, _ = ds.Query(context.Background(), "user1", OperationLoadData, nil, nil)
+
+	if ds.lastOperation != OperationLoadData {
+		t.Fatalf("lastOperation = %q, want %q", ds.lastOperation, OperationLoadData)
+	}
+}
+
+// TestQueryRejectedAfterClose guards against Query running against a data
+// source that has already released its resources.
+func TestQueryRejectedAfterClose(t *testing.T) {
+	ds := &I2b2DataSource{}
+
+	if err := ds.Close(context.Background()); err != nil {
+		t.Fatalf("Close() = %v, want nil", err)
+	}
+	// Closing twice must stay a no-op.
+	if err := ds.Close(context.Background()); err != nil {
+		t.Fatalf("second Close() = %v, want nil", err)
+	}
+
+	_, err := ds.Query(context.Background(), "user1", OperationLoadData, nil, nil)
+	if err != ErrClosed {
+		t.Fatalf("Query() after Close err = %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,46 @@
+package i2b2datasource
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateConstraintLayout is the date format the CRC cell expects for panel
+// date range bounds.
+const dateConstraintLayout = "2006-01-02"
+
+// DateConstraint scopes a Panel to observations within [Start, End],
+// inclusive or exclusive per InclusiveStart/InclusiveEnd. Start and End are
+// parsed and serialized using dateConstraintLayout.
+type DateConstraint struct {
+	Start          string
+	End            string
+	InclusiveStart bool
+	InclusiveEnd   bool
+}
+
+// Validate parses Start and End and rejects a range where Start does not
+// precede End.
+func (dc DateConstraint) Validate() error {
+	start, err := time.Parse(dateConstraintLayout, dc.Start)
+	if err != nil {
+		return fmt.Errorf("i2b2datasource: parsing date range start %q: %w", dc.Start, err)
+	}
+	end, err := time.Parse(dateConstraintLayout, dc.End)
+	if err != nil {
+		return fmt.Errorf("i2b2datasource: parsing date range end %q: %w", dc.End, err)
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("i2b2datasource: date range start %s must precede end %s", dc.Start, dc.End)
+	}
+	return nil
+}
+
+// buildXML renders dc as the <panel_date_range> element expected by the CRC
+// cell.
+func (dc DateConstraint) buildXML() string {
+	return fmt.Sprintf(`<panel_date_range>
+      <panel_date_from time="STARTDATE" inclusive="%t">%s</panel_date_from>
+      <panel_date_to time="ENDDATE" inclusive="%t">%s</panel_date_to>
+    </panel_date_range>`, dc.InclusiveStart, dc.Start, dc.InclusiveEnd, dc.End)
+}
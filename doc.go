@@ -0,0 +1,7 @@
+// Package i2b2datasource implements a GeCo data source plugin backed by an
+// i2b2 hive for query execution and a Postgres database for cohort storage.
+//
+// The plugin exposes a set of named operations (see operations.go) through
+// I2b2DataSource.Query, translating GeCo query parameters into i2b2 PM/ONT/CRC
+// cell requests via the i2b2client package.
+package i2b2datasource
@@ -0,0 +1,566 @@
+package i2b2datasource
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ldsec/geco-i2b2-data-source/i2b2client"
+)
+
+// panelInput and itemInput mirror the JSON shape of the "panels" explore
+// query parameter. Boolean logic follows i2b2's default CRC setfinder
+// semantics: Items within a single panelInput are ORed (a patient matches
+// the panel if they have any one of them), and the panels themselves are
+// ANDed together by QueryDefinition. See Panel's doc comment for the
+// worked example.
+type panelInput struct {
+	Num         int             `json:"num"`
+	Items       []itemInput     `json:"items"` // at least one required; ORed together.
+	DateRange   *DateConstraint `json:"dateRange,omitempty"`
+	Occurrences int             `json:"occurrences,omitempty"`
+	Invert      bool            `json:"invert,omitempty"`
+}
+
+type itemInput struct {
+	ConceptPath   string           `json:"conceptPath"`
+	Value         *ValueConstraint `json:"value,omitempty"`
+	ModifierKey   string           `json:"modifierKey,omitempty"`
+	ModifierCD    string           `json:"modifierCD,omitempty"`
+	ModifierValue *ValueConstraint `json:"modifierValue,omitempty"`
+	PatientSetID  string           `json:"patientSetID,omitempty"`
+}
+
+// parseExploreQueryPanels decodes the JSON-encoded "panels" parameter into
+// Panels, validating any per-item value constraints it carries.
+func parseExploreQueryPanels(raw string) ([]Panel, error) {
+	var inputs []panelInput
+	if err := json.Unmarshal([]byte(raw), &inputs); err != nil {
+		return nil, fmt.Errorf("i2b2datasource: parsing panels parameter: %w", err)
+	}
+
+	panels := make([]Panel, 0, len(inputs))
+	for _, p := range inputs {
+		items := make([]Item, 0, len(p.Items))
+		for _, it := range p.Items {
+			if it.Value != nil {
+				if err := it.Value.Validate(); err != nil {
+					return nil, err
+				}
+			}
+			item := Item{
+				ConceptPath:   it.ConceptPath,
+				Value:         it.Value,
+				ModifierKey:   it.ModifierKey,
+				ModifierCD:    it.ModifierCD,
+				ModifierValue: it.ModifierValue,
+				PatientSetID:  it.PatientSetID,
+			}
+			if err := item.Validate(); err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		if p.DateRange != nil {
+			if err := p.DateRange.Validate(); err != nil {
+				return nil, err
+			}
+		}
+
+		panel := Panel{Num: p.Num, Items: items, DateRange: p.DateRange, Occurrences: p.Occurrences, Invert: p.Invert}
+		if err := panel.Validate(); err != nil {
+			return nil, err
+		}
+		panels = append(panels, panel)
+	}
+	return panels, nil
+}
+
+// ExploreQueryHandler runs an i2b2 CRC explore (patient-set) query built
+// from the panels in parameters and returns the requested result types.
+//
+// Panels may be tied together temporally via QueryDefinition's query timing
+// and per-panel Events; see exampleTwoEventTemporalQuery for how to build a
+// query where one panel's occurrence must follow another's.
+//
+// Parameters:
+//   - panels (string, required): a JSON-encoded array of panels, see
+//     panelInput/itemInput. Each panel's items are ORed together and
+//     panels are ANDed across the array (i2b2's default setfinder boolean
+//     logic); each panel must have at least one item.
+//   - timing (string, optional): "ANY" for a temporally sequenced query,
+//     defaults to "IMMEDIATE".
+//   - includeDemographics (string, optional): when "true", fetches
+//     patient_dimension demographics for the resulting patient set and
+//     returns them under outputNameExploreQueryDemographics, restricted to
+//     ds.allowedDemographicFields.
+//   - breakdowns (string, optional): comma-separated breakdown names (age,
+//     sex, vitalStatus, concept) requested alongside the patient set,
+//     returned under outputNameExploreQueryBreakdown keyed by name. An
+//     unrecognized name is a clear error rather than a silently empty
+//     result.
+//   - projectID (string, optional): runs the query against this i2b2
+//     project instead of the data source's configured i2b2.api.project,
+//     for hives hosting multiple projects. Rejected with a clear error if
+//     the configured user has no access to it.
+//   - forceRefresh (string, optional): when "true", bypasses the explore
+//     query cache (see i2b2.api.explore-cache-ttl) and always re-issues the
+//     CRC request, refreshing the cached entry with the new result.
+//   - resultTypes (string, optional): comma-separated subset of
+//     "patientSet","encounterSet","count" selecting which result output
+//     types to request from the CRC cell, defaulting to "patientSet,count"
+//     (patient-level behavior is unchanged unless "encounterSet" is
+//     explicitly requested). Requesting "patientSet" alone skips the count
+//     result entirely (cheaper for callers that only need the set), and
+//     requesting "count" alone skips outputNameExploreQueryPatientList.
+//     "encounterSet" additionally requests the visit/encounter dimension
+//     (i2b2's ENCOUNTER_SET result type) alongside whatever patient-level
+//     types were requested, returned under
+//     outputNameExploreQueryEncounterSet as encounterSetID. Panel timing
+//     (QueryDefinition.Timing/Events) is evaluated patient-centrically
+//     regardless of resultTypes; requesting an encounter set only changes
+//     which collection the CRC cell hands back for the patients the panels
+//     already selected, not how panels are temporally joined.
+//   - requestUnobfuscated (string, optional): when "true", asks the hive
+//     to skip small-count obfuscation for this query. Only honored when
+//     the configured i2b2 user holds the DATA_PROT role on the queried
+//     project (see validateUnobfuscatedAccess); rejected immediately with
+//     a clear error otherwise, without ever contacting the hive. If the
+//     role check passes but the hive's own project configuration still
+//     declines to unobfuscate, the error wraps
+//     i2b2client.ErrUnobfuscatedNotPermitted instead of silently returning
+//     an obfuscated result a caller asked to bypass.
+//   - suppressPatientSet (string, optional): when "true", asks the hive
+//     not to register a stored patient set collection for this query
+//     instance, so a query run only for its count/breakdowns doesn't
+//     pollute the user's CRC workspace and query history with a set
+//     nobody asked to keep. Rejected with a clear error if resultTypes
+//     includes "patientSet" or "encounterSet", since those explicitly ask
+//     for the set this suppresses. See CountQueryHandler, which always
+//     suppresses patient-set creation for its count-only queries.
+//
+// A query that legitimately matches zero patients is a well-formed DONE
+// response, not an error: outputNameExploreQueryPatientList's "empty" field
+// is explicitly set to true (see i2b2client.QueryResult.IsEmpty), its count
+// (when requested) is 0 rather than omitted, and includeDemographics
+// returns an empty list without an extra round trip to the hive.
+func (ds *I2b2DataSource) ExploreQueryHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	req, err := ds.parseExploreQueryRequest(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+	resultTypes, breakdownTypes := req.resultTypes, req.breakdownTypes
+
+	var cacheKey string
+	var queryResult *i2b2client.QueryResult
+	if ds.exploreCache != nil {
+		cacheKey = exploreCacheKey(req.queryDef, req.projectID, req.outputTypes, req.requestUnobfuscated, req.suppressPatientSet)
+		if parameters["forceRefresh"] != "true" {
+			queryResult, _ = ds.exploreCache.get(cacheKey)
+		}
+	}
+	if queryResult == nil {
+		queryResult, err = ds.i2b2Client.RunQuery(ctx, req.queryDef.BuildQueryXML(), req.projectID, req.requestUnobfuscated, req.suppressPatientSet, req.outputTypes...)
+		if err != nil {
+			return nil, fmt.Errorf("i2b2datasource: ExploreQuery: %w", err)
+		}
+		if ds.exploreCache != nil {
+			ds.exploreCache.put(cacheKey, queryResult)
+		}
+	}
+
+	result := map[OutputDataObjectName]interface{}{}
+
+	if wantsResultType(resultTypes, i2b2client.ResultOutputTypePatientSet) {
+		patientList := map[string]interface{}{
+			"patientSetID":    queryResult.PatientSetID,
+			"queryInstanceID": queryResult.QueryInstanceID,
+			"obfuscated":      queryResult.Obfuscated,
+			"empty":           queryResult.IsEmpty(),
+		}
+		if queryResult.ObfuscationParams != nil {
+			patientList["obfuscationParams"] = queryResult.ObfuscationParams
+		}
+		result[outputNameExploreQueryPatientList] = patientList
+	}
+
+	if wantsResultType(resultTypes, i2b2client.ResultOutputTypeEncounterSet) {
+		result[outputNameExploreQueryEncounterSet] = map[string]interface{}{
+			"encounterSetID": queryResult.EncounterSetID,
+		}
+	}
+
+	if wantsResultType(resultTypes, i2b2client.ResultOutputTypeCount) {
+		count := 0
+		if entries, ok := queryResult.Breakdowns[i2b2client.ResultOutputTypeCount]; ok && len(entries) > 0 {
+			count = entries[0].Count
+		}
+		result[outputNameExploreQueryCount] = ds.suppressCount(count)
+	}
+
+	if parameters["includeDemographics"] == "true" {
+		if queryResult.IsEmpty() {
+			result[outputNameExploreQueryDemographics] = []map[string]interface{}{}
+		} else {
+			patients, err := ds.i2b2Client.GetPatientDemographics(ctx, queryResult.PatientSetID)
+			if err != nil {
+				return nil, fmt.Errorf("i2b2datasource: ExploreQuery: fetching demographics: %w", err)
+			}
+			result[outputNameExploreQueryDemographics] = filterDemographics(patients, ds.allowedDemographicFields)
+		}
+	}
+
+	if len(breakdownTypes) > 0 {
+		result[outputNameExploreQueryBreakdown] = ds.formatBreakdowns(queryResult.Breakdowns)
+	}
+
+	return result, nil
+}
+
+// CountQueryHandler runs a lightweight i2b2 CRC query requesting only a
+// patient count (PATIENT_COUNT_XML), always passing suppressPatientSet so
+// the hive doesn't register a stored patient set collection for it.
+// Intended for quick phenotype sizing, where a caller wants a number
+// without the cost and query-history clutter of registering a patient set
+// (see ExploreQueryHandler's suppressPatientSet parameter for the same
+// behavior with resultTypes other than just "count").
+//
+// Parameters:
+//   - panels (string, required): identical to ExploreQueryHandler's.
+//   - timing (string, optional): identical to ExploreQueryHandler's.
+//   - projectID (string, optional): identical to ExploreQueryHandler's.
+//   - requestUnobfuscated (string, optional): identical to
+//     ExploreQueryHandler's.
+//
+// resultTypes, breakdowns, includeDemographics and forceRefresh do not
+// apply and are ignored: this operation neither stores a result in the
+// explore query cache nor registers outputNameExploreQueryPatientList.
+func (ds *I2b2DataSource) CountQueryHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	req, err := ds.parseExploreQueryRequest(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	queryResult, err := ds.i2b2Client.RunQuery(ctx, req.queryDef.BuildQueryXML(), req.projectID, req.requestUnobfuscated, true, i2b2client.ResultOutputTypeCount)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: CountQuery: %w", err)
+	}
+
+	count := 0
+	if entries, ok := queryResult.Breakdowns[i2b2client.ResultOutputTypeCount]; ok && len(entries) > 0 {
+		count = entries[0].Count
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameExploreQueryCount: ds.suppressCount(count),
+	}, nil
+}
+
+// exploreQueryRequest is the parsed, validated form of ExploreQueryHandler's
+// "panels"/"timing"/"resultTypes"/"breakdowns"/"projectID" parameters,
+// shared with PreviewExploreQueryHandler so a preview builds the exact same
+// query a real run would.
+type exploreQueryRequest struct {
+	queryDef            QueryDefinition
+	resultTypes         []i2b2client.ResultOutputType
+	breakdownTypes      []i2b2client.ResultOutputType
+	outputTypes         []i2b2client.ResultOutputType
+	projectID           string
+	requestUnobfuscated bool
+	suppressPatientSet  bool
+}
+
+// parseExploreQueryRequest parses and validates the panels/timing/
+// resultTypes/breakdowns/projectID parameters common to ExploreQueryHandler
+// and PreviewExploreQueryHandler, including the referenced-patient-set and
+// project-access checks.
+func (ds *I2b2DataSource) parseExploreQueryRequest(ctx context.Context, parameters map[string]string) (*exploreQueryRequest, error) {
+	if parameters["panels"] == "" {
+		return nil, fmt.Errorf("i2b2datasource: ExploreQuery requires at least one panel")
+	}
+
+	panels, err := parseExploreQueryPanels(parameters["panels"])
+	if err != nil {
+		return nil, err
+	}
+	if err := ds.validateReferencedPatientSets(ctx, panels); err != nil {
+		return nil, err
+	}
+
+	timing := QueryTimingImmediate
+	if parameters["timing"] == string(QueryTimingSequential) {
+		timing = QueryTimingSequential
+	}
+	queryDef := QueryDefinition{Timing: timing, Panels: panels}
+
+	resultTypes, err := parseResultTypes(parameters["resultTypes"])
+	if err != nil {
+		return nil, err
+	}
+	breakdownTypes, err := parseBreakdownNames(parameters["breakdowns"])
+	if err != nil {
+		return nil, err
+	}
+	outputTypes := append(append([]i2b2client.ResultOutputType{}, resultTypes...), breakdownTypes...)
+
+	projectID := parameters["projectID"]
+	if projectID != "" {
+		if err := ds.validateProjectAccess(ctx, projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	requestUnobfuscated := parameters["requestUnobfuscated"] == "true"
+	if requestUnobfuscated {
+		if err := ds.validateUnobfuscatedAccess(ctx, projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	suppressPatientSet := parameters["suppressPatientSet"] == "true"
+	if suppressPatientSet {
+		if wantsResultType(resultTypes, i2b2client.ResultOutputTypePatientSet) {
+			return nil, fmt.Errorf("i2b2datasource: ExploreQuery: suppressPatientSet cannot be combined with resultTypes including \"patientSet\"")
+		}
+		if wantsResultType(resultTypes, i2b2client.ResultOutputTypeEncounterSet) {
+			return nil, fmt.Errorf("i2b2datasource: ExploreQuery: suppressPatientSet cannot be combined with resultTypes including \"encounterSet\"")
+		}
+	}
+
+	return &exploreQueryRequest{
+		queryDef:            queryDef,
+		resultTypes:         resultTypes,
+		breakdownTypes:      breakdownTypes,
+		outputTypes:         outputTypes,
+		projectID:           projectID,
+		requestUnobfuscated: requestUnobfuscated,
+		suppressPatientSet:  suppressPatientSet,
+	}, nil
+}
+
+// PreviewExploreQueryHandler builds the exact CRC request ExploreQueryHandler
+// would send for the same parameters, and returns its serialized XML (with
+// credentials redacted) without submitting it to the hive or touching the
+// explore query cache. Useful for debugging query construction and for
+// audit logging of what would be requested before committing to a
+// potentially expensive query.
+//
+// Parameters: identical to ExploreQueryHandler's panels/timing/resultTypes/
+// breakdowns/projectID/requestUnobfuscated/suppressPatientSet; forceRefresh
+// and includeDemographics are not applicable and ignored.
+func (ds *I2b2DataSource) PreviewExploreQueryHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	req, err := ds.parseExploreQueryRequest(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	xmlBytes, err := ds.i2b2Client.PreviewRunQuery(req.queryDef.BuildQueryXML(), req.projectID, req.requestUnobfuscated, req.suppressPatientSet, req.outputTypes...)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: PreviewExploreQuery: %w", err)
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNamePreviewExploreQuery: string(xmlBytes),
+	}, nil
+}
+
+// validateReferencedPatientSets confirms every Item.PatientSetID referenced
+// by panels was previously registered as a cohort, rejecting an arbitrary
+// or another project's patient set ID with a clear error instead of
+// forwarding it straight to the CRC cell.
+//
+// The cohort table has no per-user or per-project ownership column (see
+// GetCohortByNameHandler), so this only confirms the set was registered as
+// a cohort in this data source's database, not that it belongs to the
+// requesting user specifically.
+func (ds *I2b2DataSource) validateReferencedPatientSets(ctx context.Context, panels []Panel) error {
+	for _, p := range panels {
+		for _, it := range p.Items {
+			if it.PatientSetID == "" {
+				continue
+			}
+			var registered string
+			err := ds.db.QueryRowContext(ctx,
+				`SELECT patient_set_id FROM cohort WHERE patient_set_id = $1`,
+				it.PatientSetID,
+			).Scan(&registered)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("i2b2datasource: ExploreQuery: patient set %q is not a registered cohort", it.PatientSetID)
+			}
+			if err != nil {
+				return fmt.Errorf("i2b2datasource: ExploreQuery: validating patient set %q: %w", it.PatientSetID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateProjectAccess confirms the configured i2b2 user has access to
+// projectID, so an override to a project the user can't see fails with a
+// clear error rather than a cryptic CRC permission failure.
+func (ds *I2b2DataSource) validateProjectAccess(ctx context.Context, projectID string) error {
+	userConfig, err := ds.i2b2Client.GetUserConfiguration(ctx)
+	if err != nil {
+		return fmt.Errorf("i2b2datasource: checking access to project %q: %w", projectID, err)
+	}
+	for _, project := range userConfig.Projects {
+		if project.ID == projectID {
+			return nil
+		}
+	}
+	return fmt.Errorf("i2b2datasource: user has no access to project %q", projectID)
+}
+
+// unobfuscatedAccessRole is the i2b2 project role that permits a query to
+// bypass small-count obfuscation (i2b2's protected/unrestricted data
+// access level).
+const unobfuscatedAccessRole = "DATA_PROT"
+
+// validateUnobfuscatedAccess confirms the configured i2b2 user holds
+// unobfuscatedAccessRole on projectID, so a regular user's
+// requestUnobfuscated parameter fails fast with a clear error instead of
+// being silently ignored or forwarded to the hive only to be refused
+// there. When projectID is empty (the data source's own configured
+// project), any accessible project carrying the role is accepted, since
+// the PM cell's getUserConfiguration response doesn't otherwise identify
+// which project is the client's default.
+func (ds *I2b2DataSource) validateUnobfuscatedAccess(ctx context.Context, projectID string) error {
+	userConfig, err := ds.i2b2Client.GetUserConfiguration(ctx)
+	if err != nil {
+		return fmt.Errorf("i2b2datasource: checking unobfuscated access: %w", err)
+	}
+	for _, project := range userConfig.Projects {
+		if projectID != "" && project.ID != projectID {
+			continue
+		}
+		if project.Role == unobfuscatedAccessRole {
+			return nil
+		}
+	}
+	return fmt.Errorf("i2b2datasource: configured user does not hold the %s role required to request unobfuscated results", unobfuscatedAccessRole)
+}
+
+// resultTypeNameToOutputType maps the explore query "resultTypes"
+// parameter's human-readable names to the CRC ResultOutputType requested to
+// produce them.
+var resultTypeNameToOutputType = map[string]i2b2client.ResultOutputType{
+	"patientSet":   i2b2client.ResultOutputTypePatientSet,
+	"encounterSet": i2b2client.ResultOutputTypeEncounterSet,
+	"count":        i2b2client.ResultOutputTypeCount,
+}
+
+// parseResultTypes parses the comma-separated "resultTypes" parameter,
+// defaulting to both patientSet and count when raw is empty and rejecting
+// any name that isn't in resultTypeNameToOutputType.
+func parseResultTypes(raw string) ([]i2b2client.ResultOutputType, error) {
+	if raw == "" {
+		return []i2b2client.ResultOutputType{i2b2client.ResultOutputTypePatientSet, i2b2client.ResultOutputTypeCount}, nil
+	}
+	names := strings.Split(raw, ",")
+	types := make([]i2b2client.ResultOutputType, 0, len(names))
+	for _, name := range names {
+		outputType, ok := resultTypeNameToOutputType[name]
+		if !ok {
+			return nil, fmt.Errorf("i2b2datasource: unsupported resultType %q", name)
+		}
+		types = append(types, outputType)
+	}
+	return types, nil
+}
+
+// wantsResultType reports whether resultTypes includes want.
+func wantsResultType(resultTypes []i2b2client.ResultOutputType, want i2b2client.ResultOutputType) bool {
+	for _, t := range resultTypes {
+		if t == want {
+			return true
+		}
+	}
+	return false
+}
+
+// breakdownNameToResultOutputType maps the explore query "breakdowns"
+// parameter's human-readable names to the CRC ResultOutputType that
+// produces them.
+var breakdownNameToResultOutputType = map[string]i2b2client.ResultOutputType{
+	"age":         i2b2client.ResultOutputTypeBreakdownByAge,
+	"sex":         i2b2client.ResultOutputTypeBreakdownBySex,
+	"vitalStatus": i2b2client.ResultOutputTypeBreakdownByVitalStatus,
+	"concept":     i2b2client.ResultOutputTypeBreakdownByConcept,
+}
+
+// parseBreakdownNames parses the comma-separated "breakdowns" parameter,
+// rejecting any name that isn't in breakdownNameToResultOutputType.
+func parseBreakdownNames(raw string) ([]i2b2client.ResultOutputType, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	names := strings.Split(raw, ",")
+	types := make([]i2b2client.ResultOutputType, 0, len(names))
+	for _, name := range names {
+		outputType, ok := breakdownNameToResultOutputType[name]
+		if !ok {
+			return nil, fmt.Errorf("i2b2datasource: unsupported breakdown %q", name)
+		}
+		types = append(types, outputType)
+	}
+	return types, nil
+}
+
+// formatBreakdowns converts i2b2client breakdown results into a
+// JSON-encodable map keyed by the same names accepted in the "breakdowns"
+// parameter, applying suppressCount to each entry's count.
+func (ds *I2b2DataSource) formatBreakdowns(breakdowns map[i2b2client.ResultOutputType][]i2b2client.BreakdownEntry) map[string][]map[string]interface{} {
+	out := make(map[string][]map[string]interface{}, len(breakdowns))
+	for name, outputType := range breakdownNameToResultOutputType {
+		entries, ok := breakdowns[outputType]
+		if !ok {
+			continue
+		}
+		formatted := make([]map[string]interface{}, len(entries))
+		for i, e := range entries {
+			formatted[i] = map[string]interface{}{"category": e.Category, "count": ds.suppressCount(e.Count)}
+		}
+		out[name] = formatted
+	}
+	return out
+}
+
+// suppressCount returns count unchanged, or a "<N" suppressed indicator
+// string when ds.countSuppressionThreshold is set and count falls below it,
+// so a caller can't recover a small exact value the hive would otherwise
+// report accurately.
+func (ds *I2b2DataSource) suppressCount(count int) interface{} {
+	if ds.countSuppressionThreshold > 0 && count < ds.countSuppressionThreshold {
+		return fmt.Sprintf("<%d", ds.countSuppressionThreshold)
+	}
+	return count
+}
+
+// filterDemographics projects patients down to patientID plus whatever
+// fields of age/sex/vitalStatus appear in allowed, so a misconfigured hive
+// schema can't leak sensitive columns to callers.
+func filterDemographics(patients []i2b2client.PatientDemographics, allowed []string) []map[string]interface{} {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, field := range allowed {
+		allowedSet[field] = true
+	}
+
+	out := make([]map[string]interface{}, len(patients))
+	for i, p := range patients {
+		entry := map[string]interface{}{"patientID": p.PatientID}
+		if allowedSet["age"] {
+			entry["age"] = p.Age
+		}
+		if allowedSet["sex"] {
+			entry["sex"] = p.Sex
+		}
+		if allowedSet["vitalStatus"] {
+			entry["vitalStatus"] = p.VitalStatus
+		}
+		out[i] = entry
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+package i2b2datasource
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ldsec/geco-i2b2-data-source/i2b2client"
+)
+
+// defaultExploreCacheSize bounds how many distinct explore queries
+// exploreCache retains before evicting the least recently used entry.
+const defaultExploreCacheSize = 200
+
+// exploreCacheEntry is a single cached ExploreQuery result.
+type exploreCacheEntry struct {
+	key       string
+	result    *i2b2client.QueryResult
+	expiresAt time.Time
+}
+
+// exploreCache is a concurrency-safe, size-bounded LRU cache of ExploreQuery
+// results, keyed by the query's normalized definition plus the project and
+// breakdowns it was run with. Users frequently re-run the identical query
+// (same panels/constraints) within a session, so a short TTL cache avoids
+// re-issuing the CRC request; see exploreCacheKey for the normalization
+// rules.
+type exploreCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	size  int
+	elems map[string]*list.Element
+	order *list.List // front = most recently used
+}
+
+// newExploreCache builds an exploreCache with the given TTL, applying
+// defaultExploreCacheSize when size is non-positive.
+func newExploreCache(ttl time.Duration, size int) *exploreCache {
+	if size <= 0 {
+		size = defaultExploreCacheSize
+	}
+	return &exploreCache{
+		ttl:   ttl,
+		size:  size,
+		elems: map[string]*list.Element{},
+		order: list.New(),
+	}
+}
+
+func (c *exploreCache) get(key string) (*i2b2client.QueryResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.elems[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*exploreCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.elems, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.result, true
+}
+
+func (c *exploreCache) put(key string, result *i2b2client.QueryResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.elems[key]; ok {
+		entry := elem.Value.(*exploreCacheEntry)
+		entry.result = result
+		entry.expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&exploreCacheEntry{key: key, result: result, expiresAt: time.Now().Add(c.ttl)})
+	c.elems[key] = elem
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.elems, oldest.Value.(*exploreCacheEntry).key)
+	}
+}
+
+// parseExploreCacheTTL reads the i2b2.api.explore-cache-ttl config key,
+// returning 0 (caching disabled) when it is unset.
+func parseExploreCacheTTL(config map[string]string) (time.Duration, error) {
+	raw := config["i2b2.api.explore-cache-ttl"]
+	if raw == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("i2b2datasource: parsing i2b2.api.explore-cache-ttl: %w", err)
+	}
+	return ttl, nil
+}
+
+// exploreCacheKey builds the cache key for running queryDef against
+// projectID and requesting outputTypes (the result types plus breakdowns
+// passed to RunQuery). Panels and each panel's items are sorted before
+// hashing so two queries built from the same panels/items in a different
+// order (e.g. after a UI drag-and-drop reorder) hash equally. Panel.Num is
+// folded into its key since Event references depend on it, so two queries
+// that differ only in Num are correctly treated as distinct.
+// requestUnobfuscated and suppressPatientSet are folded in too, since both
+// change what the hive is asked to do and so can change the result (e.g.
+// suppressPatientSet clears the returned PatientSetID).
+func exploreCacheKey(queryDef QueryDefinition, projectID string, outputTypes []i2b2client.ResultOutputType, requestUnobfuscated bool, suppressPatientSet bool) string {
+	panelKeys := make([]string, len(queryDef.Panels))
+	for i, p := range queryDef.Panels {
+		panelKeys[i] = panelCacheKey(p)
+	}
+	sort.Strings(panelKeys)
+
+	outputNames := make([]string, len(outputTypes))
+	for i, t := range outputTypes {
+		outputNames[i] = string(t)
+	}
+	sort.Strings(outputNames)
+
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%t\x00%t",
+		queryDef.Timing, projectID,
+		strings.Join(panelKeys, "\x01"), strings.Join(outputNames, "\x01"), requestUnobfuscated, suppressPatientSet)
+}
+
+func panelCacheKey(p Panel) string {
+	itemKeys := make([]string, len(p.Items))
+	for i, it := range p.Items {
+		itemKeys[i] = itemCacheKey(it)
+	}
+	sort.Strings(itemKeys)
+
+	eventKey := ""
+	if p.Event != nil {
+		eventKey = fmt.Sprintf("%d:%s", p.Event.PanelNum, p.Event.Operator)
+	}
+	dateRangeKey := ""
+	if p.DateRange != nil {
+		dateRangeKey = fmt.Sprintf("%+v", *p.DateRange)
+	}
+	return fmt.Sprintf("%d\x02%s\x02%s\x02%s\x02%d\x02%t",
+		p.Num, strings.Join(itemKeys, "\x01"), eventKey, dateRangeKey, p.Occurrences, p.Invert)
+}
+
+func itemCacheKey(it Item) string {
+	valueKey := ""
+	if it.Value != nil {
+		valueKey = fmt.Sprintf("%+v", *it.Value)
+	}
+	modifierValueKey := ""
+	if it.ModifierValue != nil {
+		modifierValueKey = fmt.Sprintf("%+v", *it.ModifierValue)
+	}
+	return fmt.Sprintf("%s\x03%s\x03%s\x03%s\x03%s\x03%s", i2b2client.NormalizeConceptPath(it.ConceptPath), valueKey, it.ModifierKey, it.ModifierCD, modifierValueKey, it.PatientSetID)
+}
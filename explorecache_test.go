@@ -0,0 +1,39 @@
+package i2b2datasource
+
+import "testing"
+
+// TestExploreCacheKeyOrderInsensitive checks that two queries built from the
+// same panels and items in a different order hash to the same cache key, so
+// a UI reorder that doesn't change query semantics still hits the cache.
+func TestExploreCacheKeyOrderInsensitive(t *testing.T) {
+	a := QueryDefinition{
+		Timing: QueryTimingImmediate,
+		Panels: []Panel{
+			{Num: 1, Items: []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\A"}, {ConceptPath: "\\\\i2b2\\Diagnoses\\B"}}},
+			{Num: 2, Items: []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\C"}}},
+		},
+	}
+	b := QueryDefinition{
+		Timing: QueryTimingImmediate,
+		Panels: []Panel{
+			{Num: 2, Items: []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\C"}}},
+			{Num: 1, Items: []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\B"}, {ConceptPath: "\\\\i2b2\\Diagnoses\\A"}}},
+		},
+	}
+
+	if exploreCacheKey(a, "", nil, false, false) != exploreCacheKey(b, "", nil, false, false) {
+		t.Fatalf("exploreCacheKey differs for reordered panels/items:\na: %s\nb: %s", exploreCacheKey(a, "", nil, false, false), exploreCacheKey(b, "", nil, false, false))
+	}
+}
+
+// TestExploreCacheKeyDistinguishesPanelNum checks that swapping which panel
+// number carries which items is treated as a distinct query, since an Event
+// elsewhere in the query may reference a panel by that Num.
+func TestExploreCacheKeyDistinguishesPanelNum(t *testing.T) {
+	a := QueryDefinition{Panels: []Panel{{Num: 1, Items: []Item{{ConceptPath: "X"}}}}}
+	b := QueryDefinition{Panels: []Panel{{Num: 2, Items: []Item{{ConceptPath: "X"}}}}}
+
+	if exploreCacheKey(a, "", nil, false, false) == exploreCacheKey(b, "", nil, false, false) {
+		t.Fatalf("exploreCacheKey should differ when Panel.Num differs")
+	}
+}
@@ -0,0 +1,150 @@
+package i2b2datasource
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ldsec/geco-i2b2-data-source/i2b2client"
+)
+
+// GetObservationFactsHandler fetches raw observation_fact records for a
+// patient set via the CRC cell's PDO interface, optionally restricted to a
+// set of concept codes, and returns them as structured, typed data.
+//
+// Parameters:
+//   - patientSetID (string, required): the i2b2 patient set to fetch facts
+//     for.
+//   - conceptCodes (string, optional): comma-separated concept_cd values to
+//     restrict the result to; unset returns facts for every concept.
+//   - limit (string, optional): caps the number of facts returned by this
+//     call; defaults to, and is itself capped by, ds.maxObservationFacts
+//     (see i2b2.api.max-observation-facts).
+//   - offset (string, optional): number of matching facts to skip, for
+//     fetching subsequent pages; defaults to 0.
+//
+// The result's outputNameGetObservationFacts entry has a "facts" list (each
+// entry has whatever subset of "patientID", "conceptCode", "startDate",
+// "value", "units" and "flag" is allowed by i2b2.api.allowed-fact-fields),
+// a "hasMore" boolean, and, when hasMore is true, the "nextOffset" to
+// request the following page. A fact whose value can't be parsed
+// (malformed nval_num on a numeric fact) is logged and skipped rather than
+// failing the whole call.
+func (ds *I2b2DataSource) GetObservationFactsHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	patientSetID := parameters["patientSetID"]
+	if patientSetID == "" {
+		return nil, fmt.Errorf("i2b2datasource: GetObservationFacts requires a patientSetID")
+	}
+
+	var conceptCodes map[string]bool
+	if raw := parameters["conceptCodes"]; raw != "" {
+		conceptCodes = make(map[string]bool)
+		for _, code := range strings.Split(raw, ",") {
+			conceptCodes[code] = true
+		}
+	}
+
+	limit := ds.maxObservationFacts
+	if rawLimit, ok := parameters["limit"]; ok && rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("i2b2datasource: invalid limit %q", rawLimit)
+		}
+		if parsed < limit {
+			limit = parsed
+		}
+	}
+
+	offset := 0
+	if rawOffset, ok := parameters["offset"]; ok && rawOffset != "" {
+		parsed, err := strconv.Atoi(rawOffset)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("i2b2datasource: invalid offset %q", rawOffset)
+		}
+		offset = parsed
+	}
+
+	facts, err := ds.i2b2Client.GetObservationFacts(ctx, patientSetID)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: GetObservationFacts: %w", err)
+	}
+
+	selected := facts
+	if conceptCodes != nil {
+		selected = selected[:0]
+		for _, f := range facts {
+			if conceptCodes[f.ConceptCD] {
+				selected = append(selected, f)
+			}
+		}
+	}
+
+	start := offset
+	if start > len(selected) {
+		start = len(selected)
+	}
+	end := start + limit
+	if end > len(selected) {
+		end = len(selected)
+	}
+	page := selected[start:end]
+	hasMore := end < len(selected)
+
+	allowed := make(map[string]bool, len(ds.allowedFactFields))
+	for _, field := range ds.allowedFactFields {
+		allowed[field] = true
+	}
+
+	out := make([]map[string]interface{}, 0, len(page))
+	for _, f := range page {
+		value, err := f.Value()
+		if err != nil {
+			loggerFromContext(ctx).Warnf("GetObservationFacts: skipping fact for patient %q, concept %q: %v", f.PatientID, f.ConceptCD, err)
+			continue
+		}
+		out = append(out, filterFactFields(f, value, allowed))
+	}
+
+	result := map[string]interface{}{
+		"facts":   out,
+		"hasMore": hasMore,
+	}
+	if hasMore {
+		result["nextOffset"] = end
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameGetObservationFacts: result,
+	}, nil
+}
+
+// filterFactFields projects f and its parsed value down to the fields in
+// allowed, so a misconfigured deployment can exclude PHI-bearing fields
+// (patientID, startDate) from the result.
+func filterFactFields(f i2b2client.ObservationFact, value i2b2client.FactValue, allowed map[string]bool) map[string]interface{} {
+	entry := map[string]interface{}{}
+	if allowed["patientID"] {
+		entry["patientID"] = f.PatientID
+	}
+	if allowed["conceptCode"] {
+		entry["conceptCode"] = f.ConceptCD
+	}
+	if allowed["startDate"] {
+		entry["startDate"] = f.StartDate
+	}
+	if allowed["value"] {
+		if value.IsNumeric {
+			entry["value"] = value.Numeric
+		} else {
+			entry["value"] = value.Text
+		}
+	}
+	if allowed["units"] && value.Units != "" {
+		entry["units"] = value.Units
+	}
+	if allowed["flag"] && value.Flag != i2b2client.ValueFlagNone {
+		entry["flag"] = string(value.Flag)
+	}
+	return entry
+}
@@ -0,0 +1,54 @@
+package i2b2datasource
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ldsec/geco-i2b2-data-source/i2b2client"
+)
+
+func TestFilterFactFields(t *testing.T) {
+	fact := i2b2client.ObservationFact{PatientID: "p1", ConceptCD: "LOINC:1", StartDate: "2020-01-01"}
+	value := i2b2client.FactValue{IsNumeric: true, Numeric: 7.5, Units: "mg/dL", Flag: i2b2client.ValueFlagHigh}
+
+	tests := []struct {
+		name    string
+		allowed map[string]bool
+		want    map[string]interface{}
+	}{
+		{
+			"all fields allowed",
+			map[string]bool{"patientID": true, "conceptCode": true, "startDate": true, "value": true, "units": true, "flag": true},
+			map[string]interface{}{
+				"patientID": "p1", "conceptCode": "LOINC:1", "startDate": "2020-01-01",
+				"value": 7.5, "units": "mg/dL", "flag": "H",
+			},
+		},
+		{
+			"PHI-bearing fields excluded",
+			map[string]bool{"conceptCode": true, "value": true},
+			map[string]interface{}{"conceptCode": "LOINC:1", "value": 7.5},
+		},
+		{
+			"nothing allowed",
+			map[string]bool{},
+			map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterFactFields(fact, value, tt.allowed)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterFactFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetObservationFactsHandlerRequiresPatientSetID(t *testing.T) {
+	ds := &I2b2DataSource{allowedFactFields: defaultAllowedFactFields, maxObservationFacts: defaultMaxObservationFacts}
+	if _, err := ds.GetObservationFactsHandler(context.Background(), map[string]string{}); err == nil {
+		t.Fatal("GetObservationFactsHandler() error = nil, want error for missing patientSetID")
+	}
+}
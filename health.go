@@ -0,0 +1,50 @@
+package i2b2datasource
+
+import (
+	"context"
+	"time"
+
+	"github.com/ldsec/geco-i2b2-data-source/i2b2client"
+)
+
+// BackendHealth reports the reachability and observed latency of a single
+// backend probed by HealthCheck.
+type BackendHealth struct {
+	Healthy bool
+	Latency time.Duration
+	Error   string
+}
+
+// HealthStatus is the result of a HealthCheck call.
+type HealthStatus struct {
+	I2b2 BackendHealth
+	DB   BackendHealth
+
+	// I2b2CircuitBreaker is the i2b2 client's circuit breaker state (see
+	// i2b2client.WithCircuitBreaker) at the time of this check.
+	// i2b2client.CircuitBreakerClosed when the breaker isn't configured, so
+	// it can always be surfaced regardless of whether
+	// i2b2.api.circuit-breaker-threshold is set.
+	I2b2CircuitBreaker i2b2client.CircuitBreakerState
+}
+
+// HealthCheck probes the i2b2 hive and the Postgres cohort store
+// independently of Query, so GeCo can use it as a readiness/liveness probe
+// before routing traffic to this data source.
+func (ds *I2b2DataSource) HealthCheck(ctx context.Context) HealthStatus {
+	return HealthStatus{
+		I2b2:               probe(func() error { return ds.i2b2Client.Ping(ctx) }),
+		DB:                 probe(func() error { return ds.db.Ping(ctx) }),
+		I2b2CircuitBreaker: ds.i2b2Client.CircuitBreakerState(),
+	}
+}
+
+func probe(fn func() error) BackendHealth {
+	start := time.Now()
+	err := fn()
+	health := BackendHealth{Healthy: err == nil, Latency: time.Since(start)}
+	if err != nil {
+		health.Error = err.Error()
+	}
+	return health
+}
@@ -0,0 +1,28 @@
+package i2b2client
+
+// Default service path suffixes appended to ConnectionInfo.HiveURL to reach
+// each i2b2 cell.
+const (
+	defaultPMCellPath   = "/i2b2/services/PMService/"
+	defaultONTCellPath  = "/i2b2/services/OntologyService/"
+	defaultCRCCellPath  = "/i2b2/services/QueryToolService/"
+	defaultWorkCellPath = "/i2b2/services/WorkplaceService/"
+)
+
+// cellURLs resolves the per-cell URLs for conn from the hive base URL and
+// each cell's path suffix, falling back to the defaults above for any of
+// ConnectionInfo's PMCellPath/ONTCellPath/CRCCellPath/WorkCellPath left
+// unset, e.g. for a reverse proxy that rewrites i2b2's default layout.
+func cellURLs(conn ConnectionInfo) (pmURL, ontURL, crcURL, workURL string) {
+	return conn.HiveURL + cellPathOrDefault(conn.PMCellPath, defaultPMCellPath),
+		conn.HiveURL + cellPathOrDefault(conn.ONTCellPath, defaultONTCellPath),
+		conn.HiveURL + cellPathOrDefault(conn.CRCCellPath, defaultCRCCellPath),
+		conn.HiveURL + cellPathOrDefault(conn.WorkCellPath, defaultWorkCellPath)
+}
+
+func cellPathOrDefault(path, defaultPath string) string {
+	if path == "" {
+		return defaultPath
+	}
+	return path
+}
@@ -0,0 +1,114 @@
+package i2b2client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerState is the current state of a Client's circuit breaker,
+// exposed so an embedding application's health check can report it
+// alongside a raw Ping.
+type CircuitBreakerState string
+
+const (
+	// CircuitBreakerClosed is the normal state: requests are sent to the
+	// hive as usual.
+	CircuitBreakerClosed CircuitBreakerState = "closed"
+
+	// CircuitBreakerOpen means consecutive failures reached the configured
+	// threshold; requests fail fast with ErrCircuitOpen without reaching
+	// the hive until the cooldown elapses.
+	CircuitBreakerOpen CircuitBreakerState = "open"
+
+	// CircuitBreakerHalfOpen means the cooldown has elapsed and requests are
+	// being let through again as probes to see whether the hive has
+	// recovered; the first recorded outcome closes or reopens the breaker.
+	CircuitBreakerHalfOpen CircuitBreakerState = "half-open"
+)
+
+// circuitBreaker short-circuits Client.send after too many consecutive
+// failures, so a hive that is down doesn't have every incoming Query retry
+// and time out against it, amplifying load and latency on both sides. See
+// WithCircuitBreaker.
+//
+// This is a simple consecutive-failure breaker, not a rolling error-rate
+// one: any success resets the failure count to zero. While half-open, every
+// request is let through, not only the first: until one of them records its
+// outcome, any number of concurrent callers can reach a hive that has not
+// recovered.
+//
+// Because login's own request to the PM cell also goes through send, a
+// RunQuery call that fails while acquiring a session records two outcomes:
+// the PM login's (which only fails here on a genuine transport/hive
+// problem, not on a rejected credential, since an ERROR result status is
+// still a successful send) and the outer CRC call's. A hive that is merely
+// rejecting credentials therefore doesn't trip the breaker on its own; it
+// exists to shed load against a hive that isn't answering at all.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu                  sync.Mutex
+	state               CircuitBreakerState
+	consecutiveFailures int
+	openedAt            time.Time
+}
+
+// newCircuitBreaker builds a circuitBreaker that opens after threshold
+// consecutive failures and stays open for cooldown before probing again.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: CircuitBreakerClosed}
+}
+
+// allow reports whether a request should proceed, transitioning an open
+// breaker to half-open once the cooldown has elapsed.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != CircuitBreakerOpen {
+		return nil
+	}
+	if time.Since(b.openedAt) < b.cooldown {
+		return classify(ErrCircuitOpen, fmt.Errorf("i2b2client: circuit breaker open since %s, cooldown %s", b.openedAt.Format(time.RFC3339), b.cooldown))
+	}
+	b.state = CircuitBreakerHalfOpen
+	return nil
+}
+
+// recordResult updates the breaker's failure count and state based on the
+// outcome of a request that allow permitted.
+func (b *circuitBreaker) recordResult(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.consecutiveFailures = 0
+		b.state = CircuitBreakerClosed
+		return
+	}
+
+	b.consecutiveFailures++
+	if b.state == CircuitBreakerHalfOpen || b.consecutiveFailures >= b.threshold {
+		b.state = CircuitBreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// State returns the breaker's current state.
+func (b *circuitBreaker) State() CircuitBreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// CircuitBreakerState returns the current state of c's circuit breaker, or
+// CircuitBreakerClosed if WithCircuitBreaker was never configured (the
+// breaker can then never open).
+func (c *Client) CircuitBreakerState() CircuitBreakerState {
+	if c.breaker == nil {
+		return CircuitBreakerClosed
+	}
+	return c.breaker.State()
+}
@@ -0,0 +1,74 @@
+package i2b2client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b := newCircuitBreaker(2, time.Hour)
+	if got := b.State(); got != CircuitBreakerClosed {
+		t.Fatalf("initial State() = %q, want %q", got, CircuitBreakerClosed)
+	}
+
+	failure := errors.New("boom")
+	b.recordResult(failure)
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after 1 failure = %v, want nil (threshold not yet reached)", err)
+	}
+
+	b.recordResult(failure)
+	if got := b.State(); got != CircuitBreakerOpen {
+		t.Fatalf("State() after reaching threshold = %q, want %q", got, CircuitBreakerOpen)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() while open = %v, want errors.Is(err, ErrCircuitOpen)", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbeRecovers(t *testing.T) {
+	b := newCircuitBreaker(1, time.Millisecond)
+	b.recordResult(errors.New("boom"))
+	if got := b.State(); got != CircuitBreakerOpen {
+		t.Fatalf("State() after 1 failure (threshold 1) = %q, want %q", got, CircuitBreakerOpen)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after cooldown elapsed = %v, want nil (probe should be let through)", err)
+	}
+	if got := b.State(); got != CircuitBreakerHalfOpen {
+		t.Fatalf("State() after cooldown elapsed = %q, want %q", got, CircuitBreakerHalfOpen)
+	}
+
+	b.recordResult(nil)
+	if got := b.State(); got != CircuitBreakerClosed {
+		t.Fatalf("State() after successful probe = %q, want %q", got, CircuitBreakerClosed)
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbeFailureReopens(t *testing.T) {
+	b := newCircuitBreaker(1, time.Millisecond)
+	b.recordResult(errors.New("boom"))
+	time.Sleep(2 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after cooldown elapsed = %v, want nil", err)
+	}
+
+	b.recordResult(errors.New("still down"))
+	if got := b.State(); got != CircuitBreakerOpen {
+		t.Fatalf("State() after failed probe = %q, want %q", got, CircuitBreakerOpen)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() immediately after a reopened breaker = %v, want errors.Is(err, ErrCircuitOpen)", err)
+	}
+}
+
+func TestClientCircuitBreakerStateNoBreakerConfigured(t *testing.T) {
+	server := newMockHiveServer(t)
+	c := server.client()
+	if got := c.CircuitBreakerState(); got != CircuitBreakerClosed {
+		t.Fatalf("CircuitBreakerState() with no breaker configured = %q, want %q", got, CircuitBreakerClosed)
+	}
+}
@@ -0,0 +1,379 @@
+package i2b2client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// Client is a minimal i2b2 hive client. It owns the HTTP transport and the
+// per-cell URLs derived from ConnectionInfo, and is safe to reuse across
+// requests.
+type Client struct {
+	conn ConnectionInfo
+	http *http.Client
+
+	// transport is the http.Client's RoundTripper, kept as a concrete
+	// *http.Transport so ClientOptions can adjust TLS and proxy settings
+	// after construction.
+	transport *http.Transport
+
+	pmCellURL   string
+	ontCellURL  string
+	crcCellURL  string
+	workCellURL string
+
+	maxRetries     int
+	retryBaseDelay time.Duration
+
+	// httpTimeout bounds the client-side HTTP round trip (including
+	// retries), independent of conn.WaitTime, which only tells the hive how
+	// long *it* should wait for a synchronous result. Zero (the default)
+	// falls back to conn.WaitTime, preserving the historical behavior of a
+	// single combined timeout. See WithHTTPTimeout.
+	httpTimeout time.Duration
+
+	// gzipRequests compresses outgoing request bodies with
+	// Content-Encoding: gzip. Responses are always decompressed
+	// transparently when the hive sends Content-Encoding: gzip, regardless
+	// of this setting.
+	gzipRequests bool
+
+	// dumpMessages logs the full outgoing request XML and raw response body
+	// at debug level, with the security password redacted. See
+	// WithDumpMessages.
+	dumpMessages bool
+
+	// metrics receives request latency observations; defaults to
+	// noopMetrics. See WithMetrics.
+	metrics Metrics
+
+	// requestSem, when set via WithMaxConcurrentRequests, bounds how many
+	// HTTP requests are in flight at once across all operations. nil means
+	// unbounded.
+	requestSem *semaphore.Weighted
+
+	// userAgent is sent as the outgoing User-Agent header on every request.
+	// See WithUserAgent.
+	userAgent string
+
+	// traceHeaderName is the outgoing HTTP header a caller's trace ID (see
+	// ContextWithTraceID) is sent under. See WithTraceHeaderName.
+	traceHeaderName string
+
+	// minPollInterval and maxPollInterval clamp the interval Poll waits
+	// between attempts, overriding a hive-advertised interval_ms that falls
+	// outside that range. Zero leaves the corresponding bound unset. See
+	// WithPollIntervalBounds.
+	minPollInterval time.Duration
+	maxPollInterval time.Duration
+
+	// maxPollCount caps the number of attempts Poll makes before giving up,
+	// as a safety valve independent of the deadline. Zero (the default)
+	// leaves it unbounded. See WithMaxPollCount.
+	maxPollCount int
+
+	// maxResponseBytes caps how many bytes of a response body doPost
+	// buffers before giving up with ErrResponseTooLarge. Zero (the default)
+	// leaves it unbounded. See WithMaxResponseBytes.
+	maxResponseBytes int64
+
+	// breaker, when set via WithCircuitBreaker, short-circuits send after
+	// too many consecutive failures. nil (the default) leaves requests
+	// unaffected.
+	breaker *circuitBreaker
+
+	sessionFields
+}
+
+// defaultVersionCompatible and defaultApplicationVersion are the version
+// strings we've negotiated against the hives we currently run against (i2b2
+// 1.7.x). They apply whenever ConnectionInfo leaves the corresponding field
+// unset, so existing callers keep working unchanged.
+const (
+	defaultVersionCompatible  = "0.3"
+	defaultApplicationVersion = "1.7"
+	defaultDateTimeFormat     = time.RFC3339
+
+	// defaultProcessingID and defaultProcessingMode preserve the values
+	// every request used before ConnectionInfo.ProcessingID/ProcessingMode
+	// existed.
+	defaultProcessingID   = "P"
+	defaultProcessingMode = "I"
+)
+
+// NewClient builds a Client for the given connection info, applying any
+// ClientOptions.
+func NewClient(conn ConnectionInfo, opts ...ClientOption) *Client {
+	if conn.VersionCompatible == "" {
+		conn.VersionCompatible = defaultVersionCompatible
+	}
+	if conn.ApplicationVersion == "" {
+		conn.ApplicationVersion = defaultApplicationVersion
+	}
+	if conn.DateTimeFormat == "" {
+		conn.DateTimeFormat = defaultDateTimeFormat
+	}
+	if conn.TimeZone == nil {
+		conn.TimeZone = time.UTC
+	}
+	if conn.ProcessingID == "" {
+		conn.ProcessingID = defaultProcessingID
+	}
+	if conn.ProcessingMode == "" {
+		conn.ProcessingMode = defaultProcessingMode
+	}
+	conn.PMCellPath = cellPathOrDefault(conn.PMCellPath, defaultPMCellPath)
+	conn.ONTCellPath = cellPathOrDefault(conn.ONTCellPath, defaultONTCellPath)
+	conn.CRCCellPath = cellPathOrDefault(conn.CRCCellPath, defaultCRCCellPath)
+	conn.WorkCellPath = cellPathOrDefault(conn.WorkCellPath, defaultWorkCellPath)
+
+	pmURL, ontURL, crcURL, workURL := cellURLs(conn)
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	c := &Client{
+		conn:        conn,
+		http:        &http.Client{Transport: transport},
+		transport:   transport,
+		pmCellURL:   pmURL,
+		ontCellURL:  ontURL,
+		crcCellURL:  crcURL,
+		workCellURL: workURL,
+		metrics:     noopMetrics{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.userAgent == "" {
+		c.userAgent = fmt.Sprintf("geco-i2b2-data-source (i2b2/%s)", conn.ApplicationVersion)
+	}
+	if c.traceHeaderName == "" {
+		c.traceHeaderName = DefaultTraceHeaderName
+	}
+	return c
+}
+
+// statusChecker is implemented by response types that carry an i2b2 result
+// status, letting send peek at it to decide whether a session needs
+// refreshing without forcing every caller to re-implement that check.
+type statusChecker interface {
+	CheckStatus() error
+}
+
+// send marshals req, posts it to url, and unmarshals the response into out.
+// If ctx carries no deadline of its own (e.g. context.Background()), post
+// derives a child context bounded by c.httpTimeout, or by c.conn.WaitTime
+// when that is unset, so a hung hive cannot block the caller indefinitely.
+//
+// Requests other than the PM login itself are authenticated with a cached
+// session token rather than the raw password; a session the hive reports as
+// expired or invalid triggers one transparent re-login and retry.
+//
+// When ctx carries a trace ID (see ContextWithTraceID), it is prefixed onto
+// req's message_num so the message control id sent to the hive can be tied
+// back to the caller's correlation ID, alongside the per-message uniqueness
+// nextMessageControlID already provides.
+//
+// When WithCircuitBreaker is configured and the breaker is open, send fails
+// fast with ErrCircuitOpen without reaching the hive at all.
+func (c *Client) send(ctx context.Context, url string, req *Request, out interface{}) error {
+	if c.breaker != nil {
+		if err := c.breaker.allow(); err != nil {
+			return err
+		}
+	}
+
+	if traceID, ok := traceIDFromContext(ctx); ok {
+		req.MessageHeader.MessageControlIDMessageNum = traceID + "-" + req.MessageHeader.MessageControlIDMessageNum
+	}
+	start := time.Now()
+	err := c.sendAttempt(ctx, url, req, out, true)
+	duration := time.Since(start)
+	c.metrics.ObserveRequestLatency(url, duration, err)
+	durationAccumulatorFromContext(ctx).add(duration)
+	if c.breaker != nil {
+		c.breaker.recordResult(err)
+	}
+	return err
+}
+
+// sendChecked sends req to url, unmarshals the response into out, and
+// additionally returns out's CheckStatus error, the send-then-check
+// sequence nearly every cell method performs. Callers that need to treat
+// a non-DONE status specially (RunQuery polling through ErrProcessing, or
+// reading a raw status without rejecting it) call send and CheckStatus
+// separately instead.
+func (c *Client) sendChecked(ctx context.Context, url string, req *Request, out statusChecker) error {
+	if err := c.send(ctx, url, req, out); err != nil {
+		return err
+	}
+	return out.CheckStatus()
+}
+
+func (c *Client) sendAttempt(ctx context.Context, url string, req *Request, out interface{}, allowRetry bool) error {
+	req.SetConnectionInfo(c.conn)
+
+	if url != c.pmCellURL {
+		token, err := c.ensureSession(ctx)
+		if err != nil {
+			return fmt.Errorf("i2b2client: acquiring session: %w", err)
+		}
+		req.MessageHeader.SecurityPassword = token
+		req.MessageHeader.MessageControlIDSessionID = token
+	}
+
+	payload, err := xml.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("i2b2client: marshalling request: %w", err)
+	}
+	if c.dumpMessages {
+		loggerFromContext(ctx).Debugf("i2b2client: request to %s: %s", url, redactPassword(payload))
+	}
+
+	raw, err := c.post(ctx, url, payload)
+	if err != nil {
+		return err
+	}
+	if c.dumpMessages {
+		loggerFromContext(ctx).Debugf("i2b2client: response from %s: %s", url, raw)
+	}
+
+	if err := xml.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("i2b2client: decoding response from %s: %w", url, classify(ErrInvalidResponse, err))
+	}
+
+	if checker, ok := out.(statusChecker); ok && allowRetry && url != c.pmCellURL {
+		if statusErr := checker.CheckStatus(); statusErr != nil && isSessionExpired(statusErr) {
+			c.invalidateSession()
+			return c.sendAttempt(ctx, url, req, out, false)
+		}
+	}
+	return nil
+}
+
+// post sends body to url and returns the raw response body, retrying on
+// network errors and 5xx responses with exponential backoff up to
+// c.maxRetries additional attempts. Retries stop immediately if ctx is
+// cancelled.
+//
+// When c.requestSem is set, post waits for a free slot before issuing its
+// first attempt and holds it across any retries, so at most
+// WithMaxConcurrentRequests requests are ever in flight at once; a caller
+// waiting for a slot is released as soon as ctx is cancelled.
+func (c *Client) post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	ctx, cancel := c.withDefaultDeadline(ctx)
+	defer cancel()
+
+	if c.requestSem != nil {
+		if err := c.requestSem.Acquire(ctx, 1); err != nil {
+			return nil, fmt.Errorf("i2b2client: waiting for a request slot: %w", err)
+		}
+		defer c.requestSem.Release(1)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoffDelay(c.retryBaseDelay, attempt)):
+			}
+		}
+
+		respBody, status, err := c.doPost(ctx, url, body)
+		if err == nil && !isRetryableStatus(status) {
+			return respBody, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("i2b2client: received status %d from %s", status, url)
+		}
+		lastErr = err
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("i2b2client: request to %s failed after %d attempt(s): %w", url, c.maxRetries+1, classify(ErrHiveUnavailable, lastErr))
+}
+
+// doPost performs a single HTTP POST attempt and returns the response body
+// and status code. Outgoing bodies are gzip-compressed when c.gzipRequests
+// is set; incoming bodies are transparently gunzipped whenever the hive
+// sends Content-Encoding: gzip, independent of that setting.
+//
+// Every request carries c.userAgent as its User-Agent header, and, when ctx
+// was derived from ContextWithTraceID, the attached trace ID under
+// c.traceHeaderName, so operators can correlate this request across GeCo and
+// i2b2 access logs.
+func (c *Client) doPost(ctx context.Context, url string, body []byte) ([]byte, int, error) {
+	contentEncoding := ""
+	if c.gzipRequests {
+		gzipped, err := gzipCompress(body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("i2b2client: gzip-compressing request to %s: %w", url, err)
+		}
+		body = gzipped
+		contentEncoding = "gzip"
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("i2b2client: building request to %s: %w", url, err)
+	}
+	httpReq.Header.Set("Content-Type", "application/xml")
+	httpReq.Header.Set("Accept-Encoding", "gzip")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if contentEncoding != "" {
+		httpReq.Header.Set("Content-Encoding", contentEncoding)
+	}
+	if traceID, ok := traceIDFromContext(ctx); ok {
+		httpReq.Header.Set(c.traceHeaderName, traceID)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, 0, fmt.Errorf("i2b2client: sending request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	bodyReader := resp.Body
+	if c.maxResponseBytes > 0 {
+		bodyReader = ioutil.NopCloser(io.LimitReader(resp.Body, c.maxResponseBytes+1))
+	}
+	respBody, err := ioutil.ReadAll(bodyReader)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("i2b2client: reading response from %s: %w", url, err)
+	}
+	if c.maxResponseBytes > 0 && int64(len(respBody)) > c.maxResponseBytes {
+		return nil, resp.StatusCode, fmt.Errorf("i2b2client: reading response from %s: %w", url, ErrResponseTooLarge)
+	}
+
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		respBody, err = gzipDecompress(respBody)
+		if err != nil {
+			return nil, resp.StatusCode, fmt.Errorf("i2b2client: decompressing response from %s: %w", url, err)
+		}
+	}
+	return respBody, resp.StatusCode, nil
+}
+
+// withDefaultDeadline derives a child context bounded by c.httpTimeout (or,
+// when that's unset, c.conn.WaitTime) when ctx carries no deadline of its
+// own (i.e. the caller passed context.Background()).
+func (c *Client) withDefaultDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := c.httpTimeout
+	if timeout <= 0 {
+		timeout = c.conn.WaitTime
+	}
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
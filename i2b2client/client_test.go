@@ -0,0 +1,168 @@
+package i2b2client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readTestdata reads a fixture file from testdata, failing the test on
+// error.
+func readTestdata(t *testing.T, name string) string {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading testdata/%s: %v", name, err)
+	}
+	return string(raw)
+}
+
+// TestClientRunQueryOverHTTP drives RunQuery against a mock hive that logs
+// the client in and returns a DONE result immediately, end to end over
+// real HTTP.
+func TestClientRunQueryOverHTTP(t *testing.T) {
+	server := newMockHiveServer(t)
+	server.enqueue(defaultPMCellPath, 200, readTestdata(t, "pm_login_success.xml"))
+	server.enqueue(defaultCRCCellPath, 200, readTestdata(t, "crc_query_response.xml"))
+
+	c := server.client()
+	result, err := c.RunQuery(context.Background(), "<query_definition/>", "", false, false, ResultOutputTypeBreakdownBySex)
+	if err != nil {
+		t.Fatalf("RunQuery: %v", err)
+	}
+	if got, want := result.PatientSetID, "PS-42"; got != want {
+		t.Errorf("PatientSetID = %q, want %q", got, want)
+	}
+	if !result.Obfuscated {
+		t.Errorf("Obfuscated = false, want true")
+	}
+}
+
+// TestClientSendCheckedPropagatesStatusError asserts sendChecked surfaces a
+// non-DONE result status as an error, the same way every cell method that
+// used to call send followed by a separate CheckStatus did, via the PM
+// cell's GetUserConfiguration as a representative example. GetUserConfiguration
+// posts straight to the PM cell without a prior login round-trip (PM-cell
+// requests skip ensureSession), so only this one response is enqueued.
+func TestClientSendCheckedPropagatesStatusError(t *testing.T) {
+	server := newMockHiveServer(t)
+	server.enqueue(defaultPMCellPath, 200, `<response>
+  <response_header>
+    <result_status>
+      <status type="ERROR">Something went wrong</status>
+    </result_status>
+  </response_header>
+  <message_body/>
+</response>`)
+
+	c := server.client()
+	_, err := c.GetUserConfiguration(context.Background())
+	if err == nil {
+		t.Fatal("GetUserConfiguration() error = nil, want an error for an ERROR result status")
+	}
+}
+
+// TestClientRunQueryRequestUnobfuscatedRefused asserts that asking RunQuery
+// to bypass obfuscation but receiving an obfuscated result back anyway (as
+// when the hive's project configuration doesn't grant that privilege)
+// surfaces as ErrUnobfuscatedNotPermitted instead of silently returning the
+// obfuscated result.
+func TestClientRunQueryRequestUnobfuscatedRefused(t *testing.T) {
+	server := newMockHiveServer(t)
+	server.enqueue(defaultPMCellPath, 200, readTestdata(t, "pm_login_success.xml"))
+	server.enqueue(defaultCRCCellPath, 200, readTestdata(t, "crc_query_response.xml"))
+
+	c := server.client()
+	_, err := c.RunQuery(context.Background(), "<query_definition/>", "", true, false)
+	if !errors.Is(err, ErrUnobfuscatedNotPermitted) {
+		t.Fatalf("RunQuery error = %v, want errors.Is(err, ErrUnobfuscatedNotPermitted)", err)
+	}
+}
+
+// TestClientLoginAuthFailure asserts a hive that rejects the configured
+// credentials surfaces as an error satisfying errors.Is(err,
+// ErrAuthentication), rather than a generic status error, so callers can
+// distinguish it from a transient hive problem.
+func TestClientLoginAuthFailure(t *testing.T) {
+	server := newMockHiveServer(t)
+	server.enqueue(defaultPMCellPath, 200, readTestdata(t, "pm_login_auth_failure.xml"))
+
+	c := server.client()
+	_, err := c.RunQuery(context.Background(), "<query_definition/>", "", false, false)
+	if !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("RunQuery error = %v, want errors.Is(err, ErrAuthentication)", err)
+	}
+}
+
+// TestClientCircuitBreakerShortCircuitsAfterConsecutiveFailures asserts
+// that once WithCircuitBreaker's threshold of consecutive failures against
+// the CRC cell is reached, a further call fails fast with ErrCircuitOpen
+// instead of hitting the hive. The PM login itself is made to succeed
+// (and its session cached) so only the CRC cell's 503s are exercised,
+// keeping this independent of login's own use of send.
+func TestClientCircuitBreakerShortCircuitsAfterConsecutiveFailures(t *testing.T) {
+	server := newMockHiveServer(t)
+	server.enqueue(defaultPMCellPath, 200, readTestdata(t, "pm_login_success.xml"))
+	server.enqueue(defaultCRCCellPath, http.StatusServiceUnavailable, "")
+	server.enqueue(defaultCRCCellPath, http.StatusServiceUnavailable, "")
+
+	c := server.client(WithCircuitBreaker(2, time.Hour))
+	for i := 0; i < 2; i++ {
+		if _, err := c.RunQuery(context.Background(), "<query_definition/>", "", false, false); !errors.Is(err, ErrHiveUnavailable) {
+			t.Fatalf("RunQuery() call %d error = %v, want errors.Is(err, ErrHiveUnavailable)", i+1, err)
+		}
+	}
+	if got := c.CircuitBreakerState(); got != CircuitBreakerOpen {
+		t.Fatalf("CircuitBreakerState() after %d consecutive failures = %q, want %q", 2, got, CircuitBreakerOpen)
+	}
+
+	_, err := c.RunQuery(context.Background(), "<query_definition/>", "", false, false)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("RunQuery() after breaker opened error = %v, want errors.Is(err, ErrCircuitOpen)", err)
+	}
+}
+
+// TestClientMaxResponseBytes asserts a response body exceeding
+// WithMaxResponseBytes is abandoned with ErrResponseTooLarge instead of
+// being buffered in full.
+func TestClientMaxResponseBytes(t *testing.T) {
+	server := newMockHiveServer(t)
+	server.enqueue(defaultPMCellPath, 200, readTestdata(t, "pm_login_success.xml"))
+	oversized := readTestdata(t, "crc_query_response.xml") + strings.Repeat(" ", 1024)
+	server.enqueue(defaultCRCCellPath, 200, oversized)
+
+	c := server.client(WithMaxResponseBytes(int64(len(oversized) - 1)))
+	_, err := c.RunQuery(context.Background(), "<query_definition/>", "", false, false)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("RunQuery error = %v, want errors.Is(err, ErrResponseTooLarge)", err)
+	}
+}
+
+// TestClientRunQueryWithPolling drives RunQuery against a mock hive that
+// reports the query as still PROCESSING once before completing, exercising
+// Poll's GET loop against the polling URL the hive advertised.
+func TestClientRunQueryWithPolling(t *testing.T) {
+	server := newMockHiveServer(t)
+	server.enqueue(defaultPMCellPath, 200, readTestdata(t, "pm_login_success.xml"))
+
+	pollPath := "/poll"
+	pollURL := server.Server.URL + pollPath
+	processing := fmt.Sprintf(readTestdata(t, "crc_query_response_processing.xml"), pollURL)
+	server.enqueue(defaultCRCCellPath, 200, processing)
+	server.enqueue(pollPath, 200, readTestdata(t, "crc_query_response.xml"))
+
+	c := server.client()
+	result, err := c.RunQuery(context.Background(), "<query_definition/>", "", false, false)
+	if err != nil {
+		t.Fatalf("RunQuery: %v", err)
+	}
+	if got, want := result.PatientSetID, "PS-42"; got != want {
+		t.Errorf("PatientSetID = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package i2b2client
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ConnectionInfo holds everything needed to talk to an i2b2 hive: the hive
+// base URL, the credentials/domain to authenticate with, and the project to
+// scope requests to.
+type ConnectionInfo struct {
+	HiveURL   string
+	Domain    string
+	Username  string
+	Password  string
+	ProjectID string
+
+	// WaitTime is sent to the hive as the synchronous result wait time
+	// (result_waittime_ms) for requests that support it.
+	WaitTime time.Duration
+
+	// VersionCompatible is sent as the request's i2b2_version_compatible
+	// field. Empty defaults to defaultVersionCompatible in NewClient.
+	VersionCompatible string
+
+	// ApplicationVersion is sent as the request's
+	// receiving_application>application_version field. Empty defaults to
+	// defaultApplicationVersion in NewClient.
+	ApplicationVersion string
+
+	// DateTimeFormat is the time.Format layout used to render the request's
+	// datetime_of_message field. Empty defaults to defaultDateTimeFormat
+	// (time.RFC3339) in NewClient.
+	DateTimeFormat string
+
+	// TimeZone is the zone datetime_of_message is rendered in. Nil defaults
+	// to UTC in NewClient.
+	TimeZone *time.Location
+
+	// ProcessingID is sent as the request's processing_id>processing_id
+	// field (HL7 table 0103): "P" (production), "T" (training), or "D"
+	// (debugging). Empty defaults to defaultProcessingID ("P") in
+	// NewClient, so test/training hives can point a differently configured
+	// client at the same code without patching it.
+	ProcessingID string
+
+	// ProcessingMode is sent as the request's
+	// processing_id>processing_mode field (HL7 table 0207): "I" (initial
+	// load), "A" (archive), "R" (restore from backup), or "T" (current
+	// processing). Empty defaults to defaultProcessingMode ("I") in
+	// NewClient.
+	ProcessingMode string
+
+	// PMCellPath, ONTCellPath, CRCCellPath, and WorkCellPath override the
+	// path suffix appended to HiveURL to reach the corresponding cell,
+	// e.g. for a reverse proxy that rewrites i2b2's default layout. Empty
+	// defaults to defaultPMCellPath/defaultONTCellPath/defaultCRCCellPath/
+	// defaultWorkCellPath in NewClient.
+	PMCellPath   string
+	ONTCellPath  string
+	CRCCellPath  string
+	WorkCellPath string
+}
+
+// allowedProcessingIDs and allowedProcessingModes are the HL7-defined
+// values ConnectionInfo.ProcessingID/ProcessingMode may take, so a typo in
+// configuration is caught by Validate instead of silently reaching the
+// hive in every request.
+var (
+	allowedProcessingIDs   = map[string]bool{"P": true, "T": true, "D": true}
+	allowedProcessingModes = map[string]bool{"I": true, "A": true, "R": true, "T": true}
+)
+
+// Validate checks that conn is complete enough to attempt a connection,
+// naming the offending field so misconfiguration fails at startup with a
+// precise message instead of surfacing as a cryptic XML error later.
+func (conn ConnectionInfo) Validate() error {
+	parsedURL, err := url.Parse(conn.HiveURL)
+	if err != nil || !parsedURL.IsAbs() || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return fmt.Errorf("i2b2client: HiveURL %q must be an absolute http(s) URL", conn.HiveURL)
+	}
+	if conn.Domain == "" {
+		return fmt.Errorf("i2b2client: Domain must not be empty")
+	}
+	if conn.Username == "" {
+		return fmt.Errorf("i2b2client: Username must not be empty")
+	}
+	if conn.Password == "" {
+		return fmt.Errorf("i2b2client: Password must not be empty")
+	}
+	if conn.ProjectID == "" {
+		return fmt.Errorf("i2b2client: ProjectID must not be empty")
+	}
+	if conn.WaitTime <= 0 {
+		return fmt.Errorf("i2b2client: WaitTime must be positive, got %s", conn.WaitTime)
+	}
+	if conn.ProcessingID != "" && !allowedProcessingIDs[conn.ProcessingID] {
+		return fmt.Errorf("i2b2client: ProcessingID %q is not a recognized HL7 processing ID (P, T, D)", conn.ProcessingID)
+	}
+	if conn.ProcessingMode != "" && !allowedProcessingModes[conn.ProcessingMode] {
+		return fmt.Errorf("i2b2client: ProcessingMode %q is not a recognized HL7 processing mode (I, A, R, T)", conn.ProcessingMode)
+	}
+
+	pmURL, ontURL, crcURL, workURL := cellURLs(conn)
+	cellURLsByName := []struct {
+		name string
+		url  string
+	}{
+		{"PM", pmURL}, {"ONT", ontURL}, {"CRC", crcURL}, {"WORK", workURL},
+	}
+	for _, cell := range cellURLsByName {
+		parsedURL, err := url.Parse(cell.url)
+		if err != nil || !parsedURL.IsAbs() {
+			return fmt.Errorf("i2b2client: %s cell URL %q (HiveURL plus its cell path) must be an absolute URL", cell.name, cell.url)
+		}
+	}
+	return nil
+}
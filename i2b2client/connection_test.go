@@ -0,0 +1,97 @@
+package i2b2client
+
+import (
+	"testing"
+	"time"
+)
+
+// validConnectionInfo returns a ConnectionInfo that passes Validate, for
+// tests to mutate a single field off of.
+func validConnectionInfo() ConnectionInfo {
+	return ConnectionInfo{
+		HiveURL:   "https://hive.example.org",
+		Domain:    "i2b2demo",
+		Username:  "user",
+		Password:  "pass",
+		ProjectID: "Demo",
+		WaitTime:  time.Second,
+	}
+}
+
+func TestConnectionInfoValidateProcessingIDAndMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(conn *ConnectionInfo)
+		wantErr bool
+	}{
+		{"empty ProcessingID and ProcessingMode are allowed", func(conn *ConnectionInfo) {}, false},
+		{"ProcessingID P", func(conn *ConnectionInfo) { conn.ProcessingID = "P" }, false},
+		{"ProcessingID T", func(conn *ConnectionInfo) { conn.ProcessingID = "T" }, false},
+		{"ProcessingID D", func(conn *ConnectionInfo) { conn.ProcessingID = "D" }, false},
+		{"ProcessingID typo", func(conn *ConnectionInfo) { conn.ProcessingID = "X" }, true},
+		{"ProcessingMode I", func(conn *ConnectionInfo) { conn.ProcessingMode = "I" }, false},
+		{"ProcessingMode A", func(conn *ConnectionInfo) { conn.ProcessingMode = "A" }, false},
+		{"ProcessingMode R", func(conn *ConnectionInfo) { conn.ProcessingMode = "R" }, false},
+		{"ProcessingMode T", func(conn *ConnectionInfo) { conn.ProcessingMode = "T" }, false},
+		{"ProcessingMode typo", func(conn *ConnectionInfo) { conn.ProcessingMode = "i" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := validConnectionInfo()
+			tt.mutate(&conn)
+			err := conn.Validate()
+			if tt.wantErr != (err != nil) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConnectionInfoValidateCellPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(conn *ConnectionInfo)
+		wantErr bool
+	}{
+		{"unset cell paths fall back to the defaults", func(conn *ConnectionInfo) {}, false},
+		{"custom cell paths are accepted", func(conn *ConnectionInfo) {
+			conn.PMCellPath = "/proxy/pm/"
+			conn.CRCCellPath = "/proxy/crc/"
+		}, false},
+		{"a cell path that breaks the composed URL is rejected", func(conn *ConnectionInfo) {
+			conn.CRCCellPath = "/crc%zz"
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := validConnectionInfo()
+			tt.mutate(&conn)
+			err := conn.Validate()
+			if tt.wantErr != (err != nil) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCellURLsFallBackToDefaults asserts cellURLs only overrides the cells
+// whose path was actually customized, leaving the others on their default
+// suffix.
+func TestCellURLsFallBackToDefaults(t *testing.T) {
+	conn := validConnectionInfo()
+	conn.CRCCellPath = "/proxy/crc/"
+
+	pmURL, ontURL, crcURL, workURL := cellURLs(conn)
+	if want := conn.HiveURL + defaultPMCellPath; pmURL != want {
+		t.Errorf("pmURL = %q, want %q", pmURL, want)
+	}
+	if want := conn.HiveURL + defaultONTCellPath; ontURL != want {
+		t.Errorf("ontURL = %q, want %q", ontURL, want)
+	}
+	if want := conn.HiveURL + "/proxy/crc/"; crcURL != want {
+		t.Errorf("crcURL = %q, want %q", crcURL, want)
+	}
+	if want := conn.HiveURL + defaultWorkCellPath; workURL != want {
+		t.Errorf("workURL = %q, want %q", workURL, want)
+	}
+}
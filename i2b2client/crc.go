@@ -0,0 +1,337 @@
+package i2b2client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ResultOutputType selects one of the CRC cell's PSM result output types:
+// the plain patient set, an encounter (visit) set, a count, or a breakdown
+// by a dimension.
+type ResultOutputType string
+
+const (
+	ResultOutputTypePatientSet             ResultOutputType = "PATIENTSET"
+	ResultOutputTypeEncounterSet           ResultOutputType = "ENCOUNTER_SET"
+	ResultOutputTypeCount                  ResultOutputType = "PATIENT_COUNT_XML"
+	ResultOutputTypeBreakdownByAge         ResultOutputType = "PATIENT_AGE_COUNT_XML"
+	ResultOutputTypeBreakdownBySex         ResultOutputType = "PATIENT_GENDER_COUNT_XML"
+	ResultOutputTypeBreakdownByVitalStatus ResultOutputType = "PATIENT_VITALSTATUS_COUNT_XML"
+	ResultOutputTypeBreakdownByConcept     ResultOutputType = "PATIENT_CONCEPT_COUNT_XML"
+)
+
+// BreakdownEntry is a single category→count pair within a breakdown result
+// instance.
+type BreakdownEntry struct {
+	Category string
+	Count    int
+}
+
+// QueryResult is the outcome of RunQuery: the resulting patient set, the
+// encounter (visit) set when ResultOutputTypeEncounterSet was requested,
+// the CRC query instance id it ran as (for later GetQueryStatus/CancelQuery
+// calls), any requested breakdowns (keyed by the ResultOutputType that
+// produced them), and whether the hive reported the result as obfuscated
+// (noise-added or low-count suppressed).
+type QueryResult struct {
+	PatientSetID    string
+	EncounterSetID  string
+	QueryInstanceID string
+	Breakdowns      map[ResultOutputType][]BreakdownEntry
+
+	Obfuscated        bool
+	ObfuscationParams map[string]string
+}
+
+type crcQueryResponse struct {
+	Response
+	Body struct {
+		QueryMasterID   string `xml:"query_master_id"`
+		QueryInstanceID string `xml:"query_instance_id"`
+		PatientSetID    string `xml:"patient_set_collection_id"`
+		EncounterSetID  string `xml:"encounter_set_collection_id"`
+		Breakdowns      []struct {
+			Type string `xml:"type,attr"`
+			Data []struct {
+				Category string `xml:"value"`
+				Count    int    `xml:"count"`
+			} `xml:"data"`
+		} `xml:"breakdown"`
+	} `xml:"message_body"`
+}
+
+// RunQuery submits queryXML (a <query_definition> body, see
+// QueryDefinition.BuildQueryXML in the i2b2datasource package) to the CRC
+// cell, requesting outputTypes (just the patient set when none are given),
+// and polls if the hive reports the query is still processing. projectID, when
+// non-empty, scopes this query to that project instead of the client's
+// configured ConnectionInfo.ProjectID, without mutating shared client
+// state. requestUnobfuscated asks the hive to skip small-count obfuscation;
+// if the hive's project config doesn't grant the configured user that
+// privilege it still returns a DONE result, but with obfuscation applied
+// and an OBFUSCATED condition attached, which RunQuery reports back as
+// ErrUnobfuscatedNotPermitted rather than silently returning obfuscated
+// data a caller asked to bypass. suppressPatientSet asks the hive to skip
+// registering a stored patient set collection for this query instance,
+// for callers that only want outputTypes such as
+// ResultOutputTypeCount/breakdowns and don't want the run left behind in
+// the user's CRC workspace/history; the returned QueryResult's
+// PatientSetID is empty in that case regardless of outputTypes.
+func (c *Client) RunQuery(ctx context.Context, queryXML string, projectID string, requestUnobfuscated bool, suppressPatientSet bool, outputTypes ...ResultOutputType) (*QueryResult, error) {
+	req := NewRequest([]byte(buildRunQueryMessageBody(queryXML, outputTypes, requestUnobfuscated, suppressPatientSet)))
+	if projectID != "" {
+		req.OverrideProjectID(projectID)
+	}
+
+	var resp crcQueryResponse
+	if err := c.send(ctx, c.crcCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: RunQuery: %w", err)
+	}
+
+	if err := resp.CheckStatus(); err != nil {
+		if !errors.Is(err, ErrProcessing) {
+			return nil, fmt.Errorf("i2b2client: RunQuery: %w", err)
+		}
+		var polled crcQueryResponse
+		if _, err := c.Poll(ctx, resp.Header.PollingURL, 0, &polled); err != nil {
+			return nil, fmt.Errorf("i2b2client: RunQuery: %w", err)
+		}
+		return checkUnobfuscatedResult(queryResultFromResponse(&polled), requestUnobfuscated)
+	}
+	return checkUnobfuscatedResult(queryResultFromResponse(&resp), requestUnobfuscated)
+}
+
+// checkUnobfuscatedResult returns ErrUnobfuscatedNotPermitted instead of
+// result when requestUnobfuscated was set but the hive obfuscated the
+// result anyway, so a caller relying on an exact count can't mistake a
+// refused bypass for one that succeeded.
+func checkUnobfuscatedResult(result *QueryResult, requestUnobfuscated bool) (*QueryResult, error) {
+	if requestUnobfuscated && result.Obfuscated {
+		return nil, classify(ErrUnobfuscatedNotPermitted, fmt.Errorf("i2b2client: RunQuery: hive project configuration still obfuscated the result"))
+	}
+	return result, nil
+}
+
+// PreviewRunQuery builds the exact CRC request RunQuery would send for
+// queryXML, projectID, requestUnobfuscated, suppressPatientSet, and
+// outputTypes, and returns its serialized XML (with credentials redacted)
+// without submitting it to the hive. See Request.Preview.
+func (c *Client) PreviewRunQuery(queryXML string, projectID string, requestUnobfuscated bool, suppressPatientSet bool, outputTypes ...ResultOutputType) ([]byte, error) {
+	req := NewRequest([]byte(buildRunQueryMessageBody(queryXML, outputTypes, requestUnobfuscated, suppressPatientSet)))
+	if projectID != "" {
+		req.OverrideProjectID(projectID)
+	}
+	xmlBytes, err := req.Preview(c.conn)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2client: PreviewRunQuery: %w", err)
+	}
+	return xmlBytes, nil
+}
+
+// IsEmpty reports whether this result legitimately matched zero patients,
+// i.e. the hive returned DONE with a PATIENT_COUNT_XML breakdown entry
+// whose count is 0, as opposed to the count simply not having been
+// requested. Callers use this to distinguish a well-formed zero-patient
+// result from an unrelated bug that failed to populate PatientSetID, both
+// of which would otherwise look identical. When ResultOutputTypeCount
+// wasn't requested, IsEmpty conservatively returns false, since there is no
+// way to tell the two cases apart from this result alone.
+func (r *QueryResult) IsEmpty() bool {
+	entries, ok := r.Breakdowns[ResultOutputTypeCount]
+	return ok && len(entries) > 0 && entries[0].Count == 0
+}
+
+func queryResultFromResponse(resp *crcQueryResponse) *QueryResult {
+	result := &QueryResult{
+		PatientSetID:    resp.Body.PatientSetID,
+		EncounterSetID:  resp.Body.EncounterSetID,
+		QueryInstanceID: resp.Body.QueryInstanceID,
+	}
+	result.Obfuscated, result.ObfuscationParams = parseObfuscation(resp.Conditions())
+
+	if len(resp.Body.Breakdowns) == 0 {
+		return result
+	}
+	result.Breakdowns = make(map[ResultOutputType][]BreakdownEntry, len(resp.Body.Breakdowns))
+	for _, b := range resp.Body.Breakdowns {
+		entries := make([]BreakdownEntry, len(b.Data))
+		for i, d := range b.Data {
+			entries[i] = BreakdownEntry{Category: d.Category, Count: d.Count}
+		}
+		result.Breakdowns[ResultOutputType(b.Type)] = entries
+	}
+	return result
+}
+
+// parseObfuscation inspects conditions for an OBFUSCATED marker (or a
+// WARNING whose text mentions obfuscation/suppression), and extracts any
+// "key=value" noise parameters the hive appended to its text, separated by
+// semicolons, e.g. "OBFUSCATED;noise_mean=0.0;noise_stddev=5.0".
+func parseObfuscation(conditions []Condition) (bool, map[string]string) {
+	for _, cond := range conditions {
+		lower := strings.ToLower(cond.Type + " " + cond.Text)
+		if !strings.Contains(lower, "obfuscat") && !strings.Contains(lower, "suppress") {
+			continue
+		}
+
+		params := map[string]string{}
+		for _, part := range strings.Split(cond.Text, ";") {
+			key, value, ok := strings.Cut(part, "=")
+			if ok {
+				params[key] = value
+			}
+		}
+		if len(params) == 0 {
+			params = nil
+		}
+		return true, params
+	}
+	return false, nil
+}
+
+// CreatePatientSetFromIDs registers an externally sourced list of patient
+// IDs as a new i2b2 patient set, e.g. for a cohort ingested via LoadData,
+// and returns its patient set ID.
+func (c *Client) CreatePatientSetFromIDs(ctx context.Context, patientIDs []string) (string, error) {
+	req := NewRequest([]byte(buildCreatePatientSetMessageBody(patientIDs)))
+
+	var resp crcQueryResponse
+	if err := c.sendChecked(ctx, c.crcCellURL, req, &resp); err != nil {
+		return "", fmt.Errorf("i2b2client: CreatePatientSetFromIDs: %w", err)
+	}
+	return resp.Body.PatientSetID, nil
+}
+
+// GetPatientSetSize resolves the number of patients in the existing i2b2
+// patient set identified by patientSetID, without re-registering it as a
+// new set (unlike CreatePatientSetFromIDs, which always creates one).
+func (c *Client) GetPatientSetSize(ctx context.Context, patientSetID string) (int, error) {
+	req := NewRequest([]byte(buildGetPatientSetSizeMessageBody(patientSetID)))
+
+	var resp crcQueryResponse
+	if err := c.sendChecked(ctx, c.crcCellURL, req, &resp); err != nil {
+		return 0, fmt.Errorf("i2b2client: GetPatientSetSize: %w", err)
+	}
+	for _, b := range resp.Body.Breakdowns {
+		if ResultOutputType(b.Type) == ResultOutputTypeCount && len(b.Data) > 0 {
+			return b.Data[0].Count, nil
+		}
+	}
+	return 0, fmt.Errorf("i2b2client: GetPatientSetSize: hive response carried no %s result", ResultOutputTypeCount)
+}
+
+// QueryStatus is the outcome of GetQueryStatus: the current i2b2 result
+// status of a previously submitted query instance, plus any conditions
+// attached to it (e.g. a partial-result warning).
+type QueryStatus struct {
+	Type       string
+	Text       string
+	Conditions []Condition
+}
+
+// GetQueryStatus fetches the current status of the CRC query instance
+// identified by queryInstanceID, without waiting for it to reach DONE the
+// way RunQuery's internal polling does. Useful for a UI that wants to show
+// progress on a long-running query independently of the goroutine that
+// originally submitted it.
+func (c *Client) GetQueryStatus(ctx context.Context, queryInstanceID string) (*QueryStatus, error) {
+	req := NewRequest([]byte(buildGetQueryInstanceStatusMessageBody(queryInstanceID)))
+
+	var resp Response
+	if err := c.send(ctx, c.crcCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: GetQueryStatus: %w", err)
+	}
+
+	status := resp.Header.ResultStatus.Status
+	return &QueryStatus{Type: status.Type, Text: status.Text, Conditions: resp.Conditions()}, nil
+}
+
+// CancelQuery requests the CRC cell abort the query instance identified by
+// queryInstanceID, e.g. so a UI's cancel button can stop a long-running
+// query instead of leaving it to run to completion unobserved.
+func (c *Client) CancelQuery(ctx context.Context, queryInstanceID string) error {
+	req := NewRequest([]byte(buildCancelQueryInstanceMessageBody(queryInstanceID)))
+
+	var resp Response
+	if err := c.sendChecked(ctx, c.crcCellURL, req, &resp); err != nil {
+		return fmt.Errorf("i2b2client: CancelQuery: %w", err)
+	}
+	return nil
+}
+
+// buildGetQueryInstanceStatusMessageBody builds the CRC PSM <psmheader>
+// message body for fetching the current status of queryInstanceID.
+func buildGetQueryInstanceStatusMessageBody(queryInstanceID string) string {
+	return fmt.Sprintf(`<crcpsmns:psmheader xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/">
+      <request_type>CRC_QRY_getQueryInstanceStatus</request_type>
+      <query_instance_id>%s</query_instance_id>
+    </crcpsmns:psmheader>`, escapeXML(queryInstanceID))
+}
+
+// buildCancelQueryInstanceMessageBody builds the CRC PSM <psmheader>
+// message body for cancelling queryInstanceID.
+func buildCancelQueryInstanceMessageBody(queryInstanceID string) string {
+	return fmt.Sprintf(`<crcpsmns:psmheader xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/">
+      <request_type>CRC_QRY_cancelQueryInstance</request_type>
+      <query_instance_id>%s</query_instance_id>
+    </crcpsmns:psmheader>`, escapeXML(queryInstanceID))
+}
+
+// buildGetPatientSetSizeMessageBody builds the CRC PSM <psmheader> message
+// body for running a new query instance from the existing patient set
+// patientSetID, requesting only a count.
+func buildGetPatientSetSizeMessageBody(patientSetID string) string {
+	return fmt.Sprintf(`<crcpsmns:psmheader xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/">
+      <request_type>CRC_QRY_runQueryInstance_fromPatientSet</request_type>
+      <patient_set_coll_id>%s</patient_set_coll_id>
+      <result_output_list><result_output priority_index="1" name="%s"/></result_output_list>
+    </crcpsmns:psmheader>`, escapeXML(patientSetID), ResultOutputTypeCount)
+}
+
+// buildCreatePatientSetMessageBody builds the CRC PSM <psmheader> message
+// body for registering patientIDs as a new patient set.
+func buildCreatePatientSetMessageBody(patientIDs []string) string {
+	var patients strings.Builder
+	for _, id := range patientIDs {
+		patients.WriteString(fmt.Sprintf("<patient_id>%s</patient_id>", escapeXML(id)))
+	}
+	return fmt.Sprintf(`<crcpsmns:psmheader xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/">
+      <request_type>CRC_QRY_runQueryInstance_fromPatientIDList</request_type>
+      <patient_id_list>%s</patient_id_list>
+    </crcpsmns:psmheader>`, patients.String())
+}
+
+// buildRunQueryMessageBody builds the CRC PSM <psmheader> message body for
+// running queryXML as a new query instance, requesting outputTypes
+// (defaulting to just the patient set) as result instances. requestUnobfuscated,
+// when true, adds a <request_unobfuscated> element asking the hive to skip
+// small-count obfuscation for this query, subject to the hive's own project
+// configuration permitting it; omitted entirely otherwise so unmodified
+// hives see the same request they always have. suppressPatientSet, when
+// true, adds a <request_no_patientset> element asking the hive not to
+// register a stored patient set collection for this query instance, so a
+// count-only or breakdown-only query doesn't leave a set behind in the
+// user's CRC workspace/history; also omitted entirely when false.
+func buildRunQueryMessageBody(queryXML string, outputTypes []ResultOutputType, requestUnobfuscated bool, suppressPatientSet bool) string {
+	if len(outputTypes) == 0 {
+		outputTypes = []ResultOutputType{ResultOutputTypePatientSet}
+	}
+	var resultOutputs strings.Builder
+	for _, t := range outputTypes {
+		resultOutputs.WriteString(fmt.Sprintf(`<result_output priority_index="1" name="%s"/>`, t))
+	}
+	var options strings.Builder
+	if requestUnobfuscated {
+		options.WriteString("<request_unobfuscated>true</request_unobfuscated>")
+	}
+	if suppressPatientSet {
+		options.WriteString("<request_no_patientset>true</request_no_patientset>")
+	}
+	return fmt.Sprintf(`<crcpsmns:psmheader xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/">
+      <request_type>CRC_QRY_runQueryInstance_fromQueryDefinition</request_type>
+      <query_definition_requirement>%s</query_definition_requirement>
+      <result_output_list>%s</result_output_list>%s
+    </crcpsmns:psmheader>`, queryXML, resultOutputs.String(), options.String())
+}
@@ -0,0 +1,26 @@
+package i2b2client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildRunQueryMessageBodySuppressPatientSet(t *testing.T) {
+	tests := []struct {
+		name               string
+		suppressPatientSet bool
+		wantContains       bool
+	}{
+		{"suppressed", true, true},
+		{"not suppressed", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := buildRunQueryMessageBody("<query_definition/>", nil, false, tt.suppressPatientSet)
+			const marker = "<request_no_patientset>true</request_no_patientset>"
+			if got := strings.Contains(body, marker); got != tt.wantContains {
+				t.Errorf("buildRunQueryMessageBody(suppressPatientSet=%t) contains %q = %v, want %v", tt.suppressPatientSet, marker, got, tt.wantContains)
+			}
+		})
+	}
+}
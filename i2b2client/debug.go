@@ -0,0 +1,23 @@
+package i2b2client
+
+import "regexp"
+
+// passwordElementPattern matches the <password>...</password> element
+// within a marshaled request's <security> block, across any whitespace or
+// attributes it might carry.
+var passwordElementPattern = regexp.MustCompile(`(?s)<password>.*?</password>`)
+
+// instanceNumElementPattern matches the <instance_num>...</instance_num>
+// element, which doubles as the session token once a request is
+// authenticated (see Client.sendAttempt) and is just as sensitive as the
+// password.
+var instanceNumElementPattern = regexp.MustCompile(`(?s)<instance_num>.*?</instance_num>`)
+
+// redactPassword replaces the contents of a marshaled request's
+// <security><password> and <message_control_id><instance_num> elements
+// with *** so dumped request XML never contains a credential in plaintext,
+// even when dumping is left on by mistake.
+func redactPassword(requestXML []byte) []byte {
+	redacted := passwordElementPattern.ReplaceAll(requestXML, []byte("<password>***</password>"))
+	return instanceNumElementPattern.ReplaceAll(redacted, []byte("<instance_num>***</instance_num>"))
+}
@@ -0,0 +1,54 @@
+package i2b2client
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DurationAccumulator totals the elapsed time across however many Client
+// requests a single logical operation makes, so a caller wrapping several
+// Client calls can report how much of that operation's total duration was
+// spent talking to the hive versus elsewhere (e.g. its own database). Safe
+// for concurrent use; the zero value accumulates starting from zero.
+type DurationAccumulator struct {
+	nanos int64
+}
+
+// add accumulates d. A nil receiver is a no-op, so callers don't need to
+// nil-check before accumulating into whatever durationAccumulatorFromContext
+// returns.
+func (a *DurationAccumulator) add(d time.Duration) {
+	if a == nil {
+		return
+	}
+	atomic.AddInt64(&a.nanos, int64(d))
+}
+
+// Duration returns the total time accumulated so far. A nil receiver
+// reports zero.
+func (a *DurationAccumulator) Duration() time.Duration {
+	if a == nil {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&a.nanos))
+}
+
+// durationAccumulatorContextKey is the unexported context key
+// ContextWithDurationAccumulator stores its value under.
+type durationAccumulatorContextKey struct{}
+
+// ContextWithDurationAccumulator returns a copy of ctx that causes every
+// Client request made with it (or a context derived from it) to add its
+// elapsed time to acc via send, letting a caller measure how much of a
+// larger operation's duration went to the hive.
+func ContextWithDurationAccumulator(ctx context.Context, acc *DurationAccumulator) context.Context {
+	return context.WithValue(ctx, durationAccumulatorContextKey{}, acc)
+}
+
+// durationAccumulatorFromContext returns the accumulator attached by
+// ContextWithDurationAccumulator, or nil when ctx carries none.
+func durationAccumulatorFromContext(ctx context.Context) *DurationAccumulator {
+	acc, _ := ctx.Value(durationAccumulatorContextKey{}).(*DurationAccumulator)
+	return acc
+}
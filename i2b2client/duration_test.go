@@ -0,0 +1,34 @@
+package i2b2client
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDurationAccumulatorFromContext(t *testing.T) {
+	if acc := durationAccumulatorFromContext(context.Background()); acc.Duration() != 0 {
+		t.Fatalf("expected no accumulator on a bare context, got %v", acc.Duration())
+	}
+
+	var acc DurationAccumulator
+	ctx := ContextWithDurationAccumulator(context.Background(), &acc)
+	got := durationAccumulatorFromContext(ctx)
+	if got != &acc {
+		t.Fatalf("durationAccumulatorFromContext returned a different accumulator than was attached")
+	}
+
+	got.add(100 * time.Millisecond)
+	got.add(50 * time.Millisecond)
+	if want := 150 * time.Millisecond; acc.Duration() != want {
+		t.Fatalf("Duration() = %v, want %v", acc.Duration(), want)
+	}
+}
+
+func TestDurationAccumulatorNilReceiver(t *testing.T) {
+	var acc *DurationAccumulator
+	acc.add(time.Second) // must not panic
+	if got := acc.Duration(); got != 0 {
+		t.Fatalf("Duration() on nil receiver = %v, want 0", got)
+	}
+}
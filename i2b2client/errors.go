@@ -0,0 +1,70 @@
+package i2b2client
+
+import "fmt"
+
+// Sentinel errors classifying the ways a request to the hive can fail, so
+// callers can distinguish them with errors.Is instead of matching on a
+// flat error string.
+var (
+	// ErrAuthentication indicates the hive rejected the configured
+	// credentials or session token.
+	ErrAuthentication = fmt.Errorf("i2b2client: authentication failed")
+
+	// ErrHiveUnavailable indicates the hive could not be reached, or kept
+	// returning retryable failures until retries were exhausted.
+	ErrHiveUnavailable = fmt.Errorf("i2b2client: hive unavailable")
+
+	// ErrInvalidResponse indicates the hive's response could not be parsed
+	// as the expected shape.
+	ErrInvalidResponse = fmt.Errorf("i2b2client: invalid response from hive")
+
+	// ErrConceptNotFound indicates the hive has no concept at the
+	// requested path.
+	ErrConceptNotFound = fmt.Errorf("i2b2client: concept not found")
+
+	// ErrDuplicateName indicates the hive rejected a rename because another
+	// query master already uses the requested name.
+	ErrDuplicateName = fmt.Errorf("i2b2client: name already in use")
+
+	// ErrResponseTooLarge indicates a response body exceeded the configured
+	// WithMaxResponseBytes limit and was abandoned before being fully read.
+	ErrResponseTooLarge = fmt.Errorf("i2b2client: response body exceeds the configured maximum size")
+
+	// ErrUnobfuscatedNotPermitted indicates RunQuery was asked to bypass
+	// small-count obfuscation but the hive's project configuration doesn't
+	// grant the configured user that privilege, so it returned an
+	// obfuscated result anyway.
+	ErrUnobfuscatedNotPermitted = fmt.Errorf("i2b2client: hive did not permit an unobfuscated result for this project/user")
+
+	// ErrCircuitOpen indicates the circuit breaker configured via
+	// WithCircuitBreaker short-circuited this request after too many
+	// consecutive failures, without contacting the hive.
+	ErrCircuitOpen = fmt.Errorf("i2b2client: circuit breaker open, hive considered unavailable")
+)
+
+// classifiedError pairs one of the sentinels above with the underlying
+// cause, so errors.Is(err, ErrAuthentication) succeeds while the error's
+// message still carries the specific cause, and errors.As/Unwrap still
+// reach it (e.g. a *StatusError for inspecting the raw hive status).
+type classifiedError struct {
+	sentinel error
+	cause    error
+}
+
+// classify wraps cause so errors.Is(err, sentinel) succeeds without
+// discarding cause from the error chain.
+func classify(sentinel, cause error) error {
+	return &classifiedError{sentinel: sentinel, cause: cause}
+}
+
+func (e *classifiedError) Error() string {
+	return fmt.Sprintf("%s: %s", e.sentinel, e.cause)
+}
+
+func (e *classifiedError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.cause
+}
@@ -0,0 +1,186 @@
+package i2b2client
+
+import (
+	"encoding/xml"
+	"flag"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// update regenerates the golden files from the current marshal output, so a
+// deliberate wire format change can be re-baselined with
+// `go test ./i2b2client/... -run Golden -update` instead of hand-editing XML.
+var update = flag.Bool("update", false, "regenerate golden fixtures from current output")
+
+// TestRequestMarshalGolden marshals a representative Request to XML and
+// compares it against a committed golden file, so a struct tag typo that
+// silently renames or drops a field (e.g. the namespace prefix mismatches
+// fixed alongside this test) shows up as a diff instead of going unnoticed.
+func TestRequestMarshalGolden(t *testing.T) {
+	originalNow := now
+	defer func() { now = originalNow }()
+	now = func() time.Time { return time.Date(2024, time.March, 2, 15, 4, 5, 0, time.UTC) }
+
+	originalCounter := atomic.LoadUint64(&messageCounter)
+	atomic.StoreUint64(&messageCounter, 0)
+	defer atomic.StoreUint64(&messageCounter, originalCounter)
+
+	req := NewRequest([]byte(`<crcpsmns:psmheader xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/"><request_type>CRC_QRY_runQueryInstance_fromQueryDefinition</request_type></crcpsmns:psmheader>`))
+	req.SetConnectionInfo(ConnectionInfo{
+		Domain:             "edu.harvard.i2b2",
+		Username:           "demo",
+		Password:           "demouser",
+		ProjectID:          "Demo",
+		WaitTime:           180 * time.Second,
+		VersionCompatible:  "1.7",
+		ApplicationVersion: "1.7",
+		ProcessingID:       "P",
+		ProcessingMode:     "I",
+	})
+
+	got, err := xml.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	compareGolden(t, filepath.Join("testdata", "request_crc_query.golden.xml"), got)
+}
+
+// TestGetPDORequestMarshalGolden marshals a getPDO_fromInputList request
+// and compares it against a known-good i2b2 request captured from the
+// reference client, guarding the crcpsmns namespace prefix on the message
+// body against regressing to a meaningless placeholder (it was previously
+// ns8, mismatched with the ns5 used for otherwise-identical PSM requests in
+// crc.go, though both declare the same namespace URI).
+func TestGetPDORequestMarshalGolden(t *testing.T) {
+	originalNow := now
+	defer func() { now = originalNow }()
+	now = func() time.Time { return time.Date(2024, time.March, 2, 15, 4, 5, 0, time.UTC) }
+
+	originalCounter := atomic.LoadUint64(&messageCounter)
+	atomic.StoreUint64(&messageCounter, 0)
+	defer atomic.StoreUint64(&messageCounter, originalCounter)
+
+	req := NewRequest([]byte(buildGetPDOFromInputListMessageBody("PS-42")))
+	req.SetConnectionInfo(ConnectionInfo{
+		Domain:             "edu.harvard.i2b2",
+		Username:           "demo",
+		Password:           "demouser",
+		ProjectID:          "Demo",
+		WaitTime:           180 * time.Second,
+		VersionCompatible:  "1.7",
+		ApplicationVersion: "1.7",
+		ProcessingID:       "P",
+		ProcessingMode:     "I",
+	})
+
+	got, err := xml.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	compareGolden(t, filepath.Join("testdata", "request_get_pdo.golden.xml"), got)
+}
+
+// TestCRCQueryResponseUnmarshalFixture unmarshals a committed i2b2 CRC
+// response fixture and asserts every field survives into both the raw
+// crcQueryResponse and the QueryResult derived from it, so a struct tag
+// typo silently dropping a field shows up as a wrong/zero value instead of
+// going unnoticed.
+func TestCRCQueryResponseUnmarshalFixture(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "crc_query_response.xml"))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	var resp crcQueryResponse
+	if err := xml.Unmarshal(raw, &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if err := resp.CheckStatus(); err != nil {
+		t.Fatalf("CheckStatus: %v", err)
+	}
+	if got, want := resp.Body.QueryMasterID, "QM-1"; got != want {
+		t.Errorf("QueryMasterID = %q, want %q", got, want)
+	}
+	if got, want := resp.Body.QueryInstanceID, "QI-1"; got != want {
+		t.Errorf("QueryInstanceID = %q, want %q", got, want)
+	}
+
+	result := queryResultFromResponse(&resp)
+	if got, want := result.PatientSetID, "PS-42"; got != want {
+		t.Errorf("PatientSetID = %q, want %q", got, want)
+	}
+	if !result.Obfuscated {
+		t.Errorf("Obfuscated = false, want true")
+	}
+	if got, want := result.ObfuscationParams["noise_mean"], "0.0"; got != want {
+		t.Errorf("ObfuscationParams[noise_mean] = %q, want %q", got, want)
+	}
+	if got, want := result.ObfuscationParams["noise_stddev"], "5.0"; got != want {
+		t.Errorf("ObfuscationParams[noise_stddev] = %q, want %q", got, want)
+	}
+
+	breakdown, ok := result.Breakdowns[ResultOutputTypeBreakdownBySex]
+	if !ok {
+		t.Fatalf("Breakdowns[%s] missing", ResultOutputTypeBreakdownBySex)
+	}
+	want := []BreakdownEntry{{Category: "Female", Count: 12}, {Category: "Male", Count: 8}}
+	if len(breakdown) != len(want) {
+		t.Fatalf("Breakdowns[%s] = %v, want %v", ResultOutputTypeBreakdownBySex, breakdown, want)
+	}
+	for i, entry := range breakdown {
+		if entry != want[i] {
+			t.Errorf("Breakdowns[%s][%d] = %+v, want %+v", ResultOutputTypeBreakdownBySex, i, entry, want[i])
+		}
+	}
+}
+
+// TestCRCQueryResponseUnmarshalZeroResultFixture unmarshals a captured DONE
+// response for a query that legitimately matched zero patients, confirming
+// it parses into a well-formed, explicitly-empty QueryResult rather than
+// something a caller could mistake for a parsing bug.
+func TestCRCQueryResponseUnmarshalZeroResultFixture(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "crc_query_response_zero_results.xml"))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	var resp crcQueryResponse
+	if err := xml.Unmarshal(raw, &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if err := resp.CheckStatus(); err != nil {
+		t.Fatalf("CheckStatus: %v", err)
+	}
+
+	result := queryResultFromResponse(&resp)
+	if got, want := result.PatientSetID, "PS-99"; got != want {
+		t.Errorf("PatientSetID = %q, want %q", got, want)
+	}
+	if !result.IsEmpty() {
+		t.Errorf("IsEmpty() = false, want true for a DONE response with a zero PATIENT_COUNT_XML entry")
+	}
+}
+
+// compareGolden compares got against the committed contents of goldenPath,
+// rewriting the file first when -update is passed.
+func compareGolden(t *testing.T, goldenPath string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+			t.Fatalf("writing golden file %s: %v", goldenPath, err)
+		}
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("reading golden file %s: %v", goldenPath, err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s mismatch; run with -update to regenerate if this is an intentional wire format change\ngot:  %s\nwant: %s", goldenPath, got, want)
+	}
+}
@@ -0,0 +1,20 @@
+package i2b2client
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+// loggerFromContext returns a log entry scoped to ctx, tagging it with the
+// caller's correlation ID (see ContextWithTraceID) when one is present, so
+// every log line a single GeCo operation produces here can be picked out of
+// the shared logger by that ID.
+func loggerFromContext(ctx context.Context) *logrus.Entry {
+	if traceID, ok := traceIDFromContext(ctx); ok {
+		return logger.WithField("correlationID", traceID)
+	}
+	return logrus.NewEntry(logger)
+}
@@ -0,0 +1,27 @@
+package i2b2client
+
+import "time"
+
+// Metrics receives i2b2 request latency observations, letting the
+// embedding application route them to whatever metrics backend (e.g.
+// Prometheus) it uses without i2b2client depending on a specific library.
+type Metrics interface {
+	// ObserveRequestLatency records how long a request to cellURL took,
+	// and the error it ultimately failed with, if any.
+	ObserveRequestLatency(cellURL string, duration time.Duration, err error)
+}
+
+// noopMetrics is used when no Metrics is configured via WithMetrics.
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequestLatency(string, time.Duration, error) {}
+
+// SetMetrics routes request latency observations to m instead of
+// discarding them. Unlike the other ClientOptions, this can be called
+// after construction so an i2b2datasource.I2b2DataSource (built from a
+// flat config map that can't carry an interface value) can attach metrics
+// once the embedding application supplies them. See WithMetrics for the
+// construction-time equivalent.
+func (c *Client) SetMetrics(m Metrics) {
+	c.metrics = m
+}
@@ -0,0 +1,79 @@
+package i2b2client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockHiveServer is a scripted stand-in for an i2b2 hive: canned responses
+// are enqueued per URL path and served in order, one per request, letting a
+// test exercise the client's login/query/poll flow against real HTTP
+// without a real hive.
+type mockHiveServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[string][]mockResponse
+}
+
+// mockResponse is a single canned HTTP response.
+type mockResponse struct {
+	status int
+	body   string
+}
+
+// newMockHiveServer starts a mockHiveServer, closed automatically when t
+// completes.
+func newMockHiveServer(t *testing.T) *mockHiveServer {
+	t.Helper()
+	m := &mockHiveServer{responses: map[string][]mockResponse{}}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.handle))
+	t.Cleanup(m.Server.Close)
+	return m
+}
+
+// enqueue appends a canned response to be returned, in order, to requests
+// hitting path.
+func (m *mockHiveServer) enqueue(path string, status int, body string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses[path] = append(m.responses[path], mockResponse{status: status, body: body})
+}
+
+func (m *mockHiveServer) handle(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	queue := m.responses[r.URL.Path]
+	if len(queue) == 0 {
+		m.mu.Unlock()
+		http.Error(w, "mockHiveServer: no canned response left for "+r.URL.Path, http.StatusInternalServerError)
+		return
+	}
+	next := queue[0]
+	if len(queue) > 1 {
+		m.responses[r.URL.Path] = queue[1:]
+	} else {
+		delete(m.responses, r.URL.Path)
+	}
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(next.status)
+	w.Write([]byte(next.body))
+}
+
+// client builds a Client whose HiveURL points at the mock server, so
+// cellURLs resolves pmCellURL/ontCellURL/crcCellURL/workCellURL to paths
+// under it.
+func (m *mockHiveServer) client(opts ...ClientOption) *Client {
+	return NewClient(ConnectionInfo{
+		HiveURL:   m.Server.URL,
+		Domain:    "test",
+		Username:  "demo",
+		Password:  "demouser",
+		ProjectID: "Demo",
+		WaitTime:  5 * time.Second,
+	}, opts...)
+}
@@ -0,0 +1,129 @@
+package i2b2client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ModifierTerm is a single modifier returned by an ONT cell modifier
+// search.
+type ModifierTerm struct {
+	Name        string `xml:"name"`
+	FullName    string `xml:"fullname"`
+	Path        string `xml:"key"`
+	Code        string `xml:"c_basecode"`
+	AppliedPath string `xml:"applied_path"`
+}
+
+// appliesTo reports whether the modifier's AppliedPath matches
+// conceptPath, using i2b2's applied_path matching semantics:
+//   - "@" applies to every concept.
+//   - a path ending in "%" matches any concept whose path has that prefix
+//     (i2b2's SQL LIKE-style wildcard).
+//   - otherwise, applies only to the exact conceptPath.
+func (m ModifierTerm) appliesTo(conceptPath string) bool {
+	switch {
+	case m.AppliedPath == "@":
+		return true
+	case strings.HasSuffix(m.AppliedPath, "%"):
+		prefix := normalizeSeparators(strings.TrimSuffix(m.AppliedPath, "%"))
+		return strings.HasPrefix(normalizeSeparators(conceptPath), prefix)
+	default:
+		return ConceptPathsEqual(m.AppliedPath, conceptPath)
+	}
+}
+
+// SearchModifierResult is a page of a SearchModifier search. HasMore
+// indicates more terms matched than fit in the requested page; NextOffset
+// is the offset to pass to the following call to continue from there.
+type SearchModifierResult struct {
+	Terms      []ModifierTerm
+	HasMore    bool
+	NextOffset int
+}
+
+type getModifierInfoResponse struct {
+	Response
+	Body struct {
+		Modifiers []ModifierTerm `xml:"modifier"`
+	} `xml:"message_body"`
+}
+
+// SearchModifier performs an i2b2 ONT cell modifier search for
+// searchString, scoped to the concept at conceptPath, and returns at most
+// limit terms starting at offset. As in SearchOntology, one extra term
+// beyond limit is requested to detect and report truncation locally.
+//
+// self_concept_key scopes the hive's own search, but hives are
+// inconsistent about whether they also filter by applied_path, so the
+// result is filtered again here against conceptPath (see
+// ModifierTerm.appliesTo) to guarantee only modifiers actually applicable
+// to that concept are returned.
+func (c *Client) SearchModifier(ctx context.Context, searchString, conceptPath string, offset, limit int) (*SearchModifierResult, error) {
+	req := NewRequest([]byte(buildGetModifierInfoMessageBody(searchString, conceptPath, offset, limit+1)))
+
+	var resp getModifierInfoResponse
+	if err := c.sendChecked(ctx, c.ontCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: SearchModifier: %w", err)
+	}
+
+	var terms []ModifierTerm
+	for _, term := range resp.Body.Modifiers {
+		if term.appliesTo(conceptPath) {
+			terms = append(terms, term)
+		}
+	}
+
+	hasMore := limit > 0 && len(terms) > limit
+	if hasMore {
+		terms = terms[:limit]
+	}
+	return &SearchModifierResult{Terms: terms, HasMore: hasMore, NextOffset: offset + len(terms)}, nil
+}
+
+// buildGetModifierInfoMessageBody builds the ONT <get_modifier_info>
+// message body for searchString, scoped to conceptPath, skipping the first
+// offset matches and requesting at most maxElements beyond that.
+func buildGetModifierInfoMessageBody(searchString, conceptPath string, offset, maxElements int) string {
+	return fmt.Sprintf(`<ontns:get_modifier_info xmlns:ontns="http://www.i2b2.org/xsd/cell/ont/1.1/">
+      <max>%d</max>
+      <skip>%d</skip>
+      <term>%s</term>
+      <self_concept_key>%s</self_concept_key>
+    </ontns:get_modifier_info>`, maxElements, offset, escapeXML(searchString), escapeXML(conceptPath))
+}
+
+// SearchModifierByName performs a free-text search for modifiers by name
+// across the entire ontology tree, unscoped to any concept's applied_path,
+// mirroring SearchOntology's get_name_info search but over the modifier
+// dimension (get_modifier_name_info). Returns at most limit terms starting
+// at offset, requesting one extra term beyond limit to detect and report
+// truncation locally, as in SearchOntology.
+func (c *Client) SearchModifierByName(ctx context.Context, searchString string, offset, limit int) (*SearchModifierResult, error) {
+	req := NewRequest([]byte(buildGetModifierNameInfoMessageBody(searchString, offset, limit+1)))
+
+	var resp getModifierInfoResponse
+	if err := c.sendChecked(ctx, c.ontCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: SearchModifierByName: %w", err)
+	}
+
+	terms := resp.Body.Modifiers
+	hasMore := limit > 0 && len(terms) > limit
+	if hasMore {
+		terms = terms[:limit]
+	}
+	return &SearchModifierResult{Terms: terms, HasMore: hasMore, NextOffset: offset + len(terms)}, nil
+}
+
+// buildGetModifierNameInfoMessageBody builds the ONT
+// <get_modifier_name_info> message body for searchString, skipping the
+// first offset matches and requesting at most maxElements beyond that,
+// unscoped to any concept.
+func buildGetModifierNameInfoMessageBody(searchString string, offset, maxElements int) string {
+	return fmt.Sprintf(`<ontns:get_modifier_name_info xmlns:ontns="http://www.i2b2.org/xsd/cell/ont/1.1/">
+      <max>%d</max>
+      <skip>%d</skip>
+      <term>%s</term>
+    </ontns:get_modifier_name_info>`, maxElements, offset, escapeXML(searchString))
+}
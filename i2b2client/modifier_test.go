@@ -0,0 +1,88 @@
+package i2b2client
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestModifierTermAppliesTo covers the three applied_path matching rules:
+// exact match, the "@" (applies to everything) wildcard, and a "%" prefix
+// wildcard.
+func TestModifierTermAppliesTo(t *testing.T) {
+	tests := []struct {
+		name        string
+		appliedPath string
+		conceptPath string
+		want        bool
+	}{
+		{
+			name:        "exact match",
+			appliedPath: `\\i2b2\Diagnoses\A`,
+			conceptPath: `\\i2b2\Diagnoses\A`,
+			want:        true,
+		},
+		{
+			name:        "exact mismatch",
+			appliedPath: `\\i2b2\Diagnoses\A`,
+			conceptPath: `\\i2b2\Diagnoses\B`,
+			want:        false,
+		},
+		{
+			name:        "@ matches any concept",
+			appliedPath: "@",
+			conceptPath: `\\i2b2\Diagnoses\A`,
+			want:        true,
+		},
+		{
+			name:        "% prefix wildcard matches descendant",
+			appliedPath: `\\i2b2\Diagnoses\%`,
+			conceptPath: `\\i2b2\Diagnoses\A`,
+			want:        true,
+		},
+		{
+			name:        "% prefix wildcard rejects non-descendant",
+			appliedPath: `\\i2b2\Diagnoses\%`,
+			conceptPath: `\\i2b2\Procedures\A`,
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := ModifierTerm{AppliedPath: tt.appliedPath}
+			if got := m.appliesTo(tt.conceptPath); got != tt.want {
+				t.Errorf("appliesTo(%q) with AppliedPath %q = %v, want %v", tt.conceptPath, tt.appliedPath, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBuildGetModifierInfoMessageBodyEscapesSearchString checks that
+// searchString/conceptPath containing XML metacharacters are escaped
+// rather than spliced verbatim into the message body, where they would
+// otherwise produce malformed XML or inject a sibling element.
+func TestBuildGetModifierInfoMessageBodyEscapesSearchString(t *testing.T) {
+	body := buildGetModifierInfoMessageBody("Mother & Child", `\\i2b2\Diagnoses\A`, 0, 20)
+
+	if strings.Contains(body, "Mother & Child") {
+		t.Fatalf("buildGetModifierInfoMessageBody() = %s, want the search string escaped", body)
+	}
+	want := "<term>Mother &amp; Child</term>"
+	if !strings.Contains(body, want) {
+		t.Fatalf("buildGetModifierInfoMessageBody() = %s, want it to contain %s", body, want)
+	}
+}
+
+// TestBuildGetModifierNameInfoMessageBodyEscapesSearchString checks the
+// same escaping for the unscoped get_modifier_name_info search.
+func TestBuildGetModifierNameInfoMessageBodyEscapesSearchString(t *testing.T) {
+	body := buildGetModifierNameInfoMessageBody("Mother & Child", 0, 20)
+
+	if strings.Contains(body, "Mother & Child") {
+		t.Fatalf("buildGetModifierNameInfoMessageBody() = %s, want the search string escaped", body)
+	}
+	want := "<term>Mother &amp; Child</term>"
+	if !strings.Contains(body, want) {
+		t.Fatalf("buildGetModifierNameInfoMessageBody() = %s, want it to contain %s", body, want)
+	}
+}
@@ -0,0 +1,251 @@
+package i2b2client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// OntTerm is a single concept returned by an ONT cell name search.
+type OntTerm struct {
+	Name             string           `xml:"name"`
+	FullName         string           `xml:"fullname"`
+	Path             string           `xml:"key"`
+	Code             string           `xml:"c_basecode"`
+	VisualAttributes VisualAttributes `xml:"visualattributes"`
+
+	// Lang is the language Name/FullName are actually rendered in. Empty
+	// when the hive doesn't support localized names at all, in which case
+	// Name/FullName are in the hive's own default language regardless of
+	// what was requested.
+	Lang string `xml:"lang"`
+}
+
+// SearchOntologyResult is a page of a SearchOntology search. HasMore
+// indicates more terms matched than fit in the requested page; NextOffset
+// is the offset to pass to the following call to continue from there.
+type SearchOntologyResult struct {
+	Terms      []OntTerm
+	HasMore    bool
+	NextOffset int
+}
+
+type getNameInfoResponse struct {
+	Response
+	Body struct {
+		Concepts []OntTerm `xml:"concept"`
+	} `xml:"message_body"`
+}
+
+// SearchOntology performs an i2b2 ONT cell name search for searchString,
+// optionally restricted to category, and returns at most limit terms
+// starting at offset. Since not every i2b2 hive honors paging itself,
+// SearchOntology requests one extra term beyond limit and uses its
+// presence to detect and report truncation locally rather than silently
+// returning a short, seemingly-complete page.
+//
+// lang optionally requests concept names localized to that language; a
+// hive without a translation for a given term falls back to its default
+// language for that term rather than failing the request, so callers
+// should check the returned OntTerm.Lang against lang to detect the
+// fallback.
+func (c *Client) SearchOntology(ctx context.Context, searchString, category, lang string, offset, limit int) (*SearchOntologyResult, error) {
+	req := NewRequest([]byte(buildGetNameInfoMessageBody(searchString, category, lang, offset, limit+1)))
+
+	var resp getNameInfoResponse
+	if err := c.sendChecked(ctx, c.ontCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: SearchOntology: %w", err)
+	}
+
+	terms := resp.Body.Concepts
+	hasMore := limit > 0 && len(terms) > limit
+	if hasMore {
+		terms = terms[:limit]
+	}
+	return &SearchOntologyResult{Terms: terms, HasMore: hasMore, NextOffset: offset + len(terms)}, nil
+}
+
+// ConceptMetadata is the metadata ONT holds for a single concept, enough
+// for a query-building UI to know how to render and validate a value for
+// it, and whether it's a leaf term or a container with children.
+type ConceptMetadata struct {
+	Path             string           `xml:"key"`
+	Name             string           `xml:"name"`
+	ValueTypeCode    string           `xml:"valuetype_cd"`
+	VisualAttributes VisualAttributes `xml:"visualattributes"`
+	Tooltip          string           `xml:"tooltip"`
+	Units            string           `xml:"units"`
+
+	// Lang is the language Name is actually rendered in; see OntTerm.Lang.
+	Lang string `xml:"lang"`
+}
+
+// IsLeaf reports whether the concept is a leaf term rather than a
+// container with children.
+func (m ConceptMetadata) IsLeaf() bool {
+	return m.VisualAttributes.IsLeaf()
+}
+
+type getTermInfoResponse struct {
+	Response
+	Body struct {
+		Concepts []ConceptMetadata `xml:"concept"`
+	} `xml:"message_body"`
+}
+
+// GetConceptMetadata issues an ONT cell get_term_info request for the
+// concept at conceptPath, with the metadata-only flags set so no
+// children are returned, and returns its parsed metadata. Returns
+// ErrConceptNotFound (checkable with errors.Is) when the hive has no
+// concept at conceptPath.
+//
+// lang optionally requests the concept's name localized to that
+// language; see SearchOntology for the fallback behavior when no
+// translation exists.
+func (c *Client) GetConceptMetadata(ctx context.Context, conceptPath, lang string) (*ConceptMetadata, error) {
+	req := NewRequest([]byte(buildGetTermInfoMessageBody(conceptPath, lang)))
+
+	var resp getTermInfoResponse
+	if err := c.sendChecked(ctx, c.ontCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: GetConceptMetadata: %w", err)
+	}
+	if len(resp.Body.Concepts) == 0 {
+		return nil, fmt.Errorf("i2b2client: GetConceptMetadata: %w", classify(ErrConceptNotFound, fmt.Errorf("no concept at path %q", conceptPath)))
+	}
+	return &resp.Body.Concepts[0], nil
+}
+
+// buildGetTermInfoMessageBody builds the ONT <ontns:get_term_info> message
+// body for conceptPath, requesting only the term's own metadata (no
+// children, via a max depth of 0), optionally localized to lang.
+func buildGetTermInfoMessageBody(conceptPath, lang string) string {
+	langElem := ""
+	if lang != "" {
+		langElem = fmt.Sprintf("<lang>%s</lang>", escapeXML(lang))
+	}
+	return fmt.Sprintf(`<ontns:get_term_info xmlns:ontns="http://www.i2b2.org/xsd/cell/ont/1.1/">
+      <self_concept_key>%s</self_concept_key>
+      <blob>false</blob>
+      <max_depth>0</max_depth>
+      %s
+    </ontns:get_term_info>`, escapeXML(conceptPath), langElem)
+}
+
+// GetChildren issues an ONT cell get_term_info request for the immediate
+// children of the concept at conceptPath (a max depth of 1, which includes
+// the concept itself alongside its children), and returns just the
+// children.
+//
+// lang optionally requests the children's names localized to that
+// language; see SearchOntology for the fallback behavior when no
+// translation exists.
+func (c *Client) GetChildren(ctx context.Context, conceptPath, lang string) ([]OntTerm, error) {
+	req := NewRequest([]byte(buildGetChildrenMessageBody(conceptPath, lang)))
+
+	var resp getNameInfoResponse
+	if err := c.sendChecked(ctx, c.ontCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: GetChildren: %w", err)
+	}
+
+	children := make([]OntTerm, 0, len(resp.Body.Concepts))
+	for _, concept := range resp.Body.Concepts {
+		if ConceptPathsEqual(concept.Path, conceptPath) {
+			continue
+		}
+		children = append(children, concept)
+	}
+	return children, nil
+}
+
+// buildGetChildrenMessageBody builds the ONT <ontns:get_term_info> message
+// body for conceptPath, requesting one level of children (max depth of 1)
+// in addition to the concept's own metadata, optionally localized to lang.
+func buildGetChildrenMessageBody(conceptPath, lang string) string {
+	langElem := ""
+	if lang != "" {
+		langElem = fmt.Sprintf("<lang>%s</lang>", escapeXML(lang))
+	}
+	return fmt.Sprintf(`<ontns:get_term_info xmlns:ontns="http://www.i2b2.org/xsd/cell/ont/1.1/">
+      <self_concept_key>%s</self_concept_key>
+      <blob>false</blob>
+      <max_depth>1</max_depth>
+      %s
+    </ontns:get_term_info>`, escapeXML(conceptPath), langElem)
+}
+
+// GetConceptsByCode resolves concept_cd basecodes to their fully-qualified
+// ontology path and display name via a single ONT cell get_basecode_info
+// request, batching all of codes into that one call. A code the hive
+// doesn't recognize is simply absent from the result, rather than causing
+// an error.
+func (c *Client) GetConceptsByCode(ctx context.Context, codes []string) ([]OntTerm, error) {
+	req := NewRequest([]byte(buildGetBasecodeInfoMessageBody(codes)))
+
+	var resp getNameInfoResponse
+	if err := c.sendChecked(ctx, c.ontCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: GetConceptsByCode: %w", err)
+	}
+	return resp.Body.Concepts, nil
+}
+
+// buildGetBasecodeInfoMessageBody builds the ONT <ontns:get_basecode_info>
+// message body resolving all of codes in a single request.
+func buildGetBasecodeInfoMessageBody(codes []string) string {
+	var basecodes strings.Builder
+	for _, code := range codes {
+		basecodes.WriteString(fmt.Sprintf("<basecode>%s</basecode>", escapeXML(code)))
+	}
+	return fmt.Sprintf(`<ontns:get_basecode_info xmlns:ontns="http://www.i2b2.org/xsd/cell/ont/1.1/">
+      %s
+    </ontns:get_basecode_info>`, basecodes.String())
+}
+
+// Scheme describes a single coding scheme (e.g. ICD-10, LOINC) the hive
+// knows about, as returned by GetSchemes.
+type Scheme struct {
+	Key         string `xml:"scheme_key"`
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+}
+
+type getSchemesResponse struct {
+	Response
+	Body struct {
+		Schemes []Scheme `xml:"scheme"`
+	} `xml:"message_body"`
+}
+
+// GetSchemes issues an ONT cell get_schemes request and returns the hive's
+// configured coding schemes, so a caller can map a concept's c_basecode
+// back to its source vocabulary (e.g. ICD-10 vs LOINC) for display.
+func (c *Client) GetSchemes(ctx context.Context) ([]Scheme, error) {
+	req := NewRequest([]byte(`<ontns:get_schemes xmlns:ontns="http://www.i2b2.org/xsd/cell/ont/1.1/"/>`))
+
+	var resp getSchemesResponse
+	if err := c.sendChecked(ctx, c.ontCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: GetSchemes: %w", err)
+	}
+	return resp.Body.Schemes, nil
+}
+
+// buildGetNameInfoMessageBody builds the ONT <ontns:get_name_info> message
+// body for searchString, optionally scoped to category and localized to
+// lang, skipping the first offset matches and requesting at most
+// maxElements beyond that.
+func buildGetNameInfoMessageBody(searchString, category, lang string, offset, maxElements int) string {
+	categoryElem := ""
+	if category != "" {
+		categoryElem = fmt.Sprintf("<category>%s</category>", escapeXML(category))
+	}
+	langElem := ""
+	if lang != "" {
+		langElem = fmt.Sprintf("<lang>%s</lang>", escapeXML(lang))
+	}
+	return fmt.Sprintf(`<ontns:get_name_info xmlns:ontns="http://www.i2b2.org/xsd/cell/ont/1.1/">
+      <max>%d</max>
+      <skip>%d</skip>
+      <term>%s</term>
+      %s
+      %s
+    </ontns:get_name_info>`, maxElements, offset, escapeXML(searchString), categoryElem, langElem)
+}
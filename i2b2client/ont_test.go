@@ -0,0 +1,60 @@
+package i2b2client
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestBuildGetNameInfoMessageBodyOmitsLangWhenEmpty checks that the
+// optional <lang> element is omitted when no language is requested,
+// mirroring the existing <category> element's behavior.
+func TestBuildGetNameInfoMessageBodyOmitsLangWhenEmpty(t *testing.T) {
+	body := buildGetNameInfoMessageBody("diabetes", "", "", 0, 20)
+
+	if strings.Contains(body, "<lang>") {
+		t.Fatalf("buildGetNameInfoMessageBody() = %s, want no <lang> element", body)
+	}
+}
+
+// TestBuildGetNameInfoMessageBodyIncludesLang checks that a requested
+// language is serialized as a <lang> element.
+func TestBuildGetNameInfoMessageBodyIncludesLang(t *testing.T) {
+	body := buildGetNameInfoMessageBody("diabetes", "", "fr", 0, 20)
+
+	want := "<lang>fr</lang>"
+	if !strings.Contains(body, want) {
+		t.Fatalf("buildGetNameInfoMessageBody() = %s, want it to contain %s", body, want)
+	}
+}
+
+// TestBuildGetNameInfoMessageBodyEscapesSearchString checks that a search
+// term containing XML metacharacters is escaped rather than spliced
+// verbatim into the message body, where it would otherwise produce
+// malformed XML or inject a sibling element.
+func TestBuildGetNameInfoMessageBodyEscapesSearchString(t *testing.T) {
+	body := buildGetNameInfoMessageBody("Mother & Child", "", "", 0, 20)
+
+	if strings.Contains(body, "Mother & Child") {
+		t.Fatalf("buildGetNameInfoMessageBody() = %s, want the search string escaped", body)
+	}
+	want := "<term>Mother &amp; Child</term>"
+	if !strings.Contains(body, want) {
+		t.Fatalf("buildGetNameInfoMessageBody() = %s, want it to contain %s", body, want)
+	}
+}
+
+// TestBuildGetBasecodeInfoMessageBodyEscapesCodes checks that each code is
+// escaped rather than spliced verbatim into its <basecode> element, and
+// that multiple codes each produce their own element.
+func TestBuildGetBasecodeInfoMessageBodyEscapesCodes(t *testing.T) {
+	body := buildGetBasecodeInfoMessageBody([]string{"ICD10:A & B", "LOINC:1234-5"})
+
+	if strings.Contains(body, "A & B") {
+		t.Fatalf("buildGetBasecodeInfoMessageBody() = %s, want the code escaped", body)
+	}
+	for _, want := range []string{"<basecode>ICD10:A &amp; B</basecode>", "<basecode>LOINC:1234-5</basecode>"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("buildGetBasecodeInfoMessageBody() = %s, want it to contain %s", body, want)
+		}
+	}
+}
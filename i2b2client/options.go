@@ -0,0 +1,178 @@
+package i2b2client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// ClientOption configures optional Client behavior at construction time.
+type ClientOption func(*Client)
+
+// WithRetry enables retrying idempotent request sends on network errors and
+// 5xx responses, up to maxRetries additional attempts beyond the first,
+// using exponential backoff with jitter starting at baseDelay. A zero
+// maxRetries disables retrying (the default).
+func WithRetry(maxRetries int, baseDelay time.Duration) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBaseDelay = baseDelay
+	}
+}
+
+// WithHTTPTimeout bounds the client-side HTTP round trip (including
+// retries) independently of ConnectionInfo.WaitTime, which only tells the
+// hive how long it should wait for a synchronous result. This lets a
+// caller set a short result wait but a longer network tolerance, or vice
+// versa, instead of the two being conflated into a single timeout.
+func WithHTTPTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.httpTimeout = timeout
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used when connecting to the
+// hive, e.g. for presenting a client certificate or trusting an internal
+// CA behind a mutual-TLS proxy. Build tlsConfig with BuildTLSConfig.
+func WithTLSConfig(tlsConfig *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.transport.TLSClientConfig = tlsConfig
+	}
+}
+
+// WithGzipRequests compresses outgoing request bodies with
+// Content-Encoding: gzip. Leave disabled (the default) for i2b2 deployments
+// that don't accept compressed request bodies; responses are decompressed
+// transparently regardless of this setting.
+func WithGzipRequests(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.gzipRequests = enabled
+	}
+}
+
+// WithProxyURL routes all i2b2 HTTP traffic through the given forward
+// proxy, overriding the default of honoring HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+// via http.ProxyFromEnvironment.
+func WithProxyURL(proxyURL *url.URL) ClientOption {
+	return func(c *Client) {
+		c.transport.Proxy = http.ProxyURL(proxyURL)
+		logger.Debugf("i2b2client: using proxy %s", proxyURL.Redacted())
+	}
+}
+
+// WithMetrics routes i2b2 request latency observations to m instead of
+// discarding them, letting the embedding application expose them through
+// whatever metrics backend it uses.
+func WithMetrics(m Metrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// WithMaxConcurrentRequests bounds the number of HTTP requests this Client
+// has in flight at once, across all operations, using a weighted semaphore
+// acquired around each request (including its retries) so a batch/breakdown
+// feature that fans out many calls concurrently cannot overwhelm the hive.
+// A non-positive maxConcurrent leaves requests unbounded (the default).
+func WithMaxConcurrentRequests(maxConcurrent int) ClientOption {
+	return func(c *Client) {
+		if maxConcurrent > 0 {
+			c.requestSem = semaphore.NewWeighted(int64(maxConcurrent))
+		}
+	}
+}
+
+// DefaultTraceHeaderName is the outbound HTTP header a caller's trace ID
+// (see ContextWithTraceID) is sent under when WithTraceHeaderName has not
+// overridden it.
+const DefaultTraceHeaderName = "X-Request-ID"
+
+// WithUserAgent overrides the User-Agent header sent with every i2b2 HTTP
+// request. Unset defaults to a descriptive value derived from
+// ConnectionInfo.ApplicationVersion, so hive access logs can at least
+// distinguish this data source's traffic from other i2b2 clients without a
+// per-site override.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTraceHeaderName overrides the HTTP header a caller's trace ID (see
+// ContextWithTraceID) is sent under, for sites with an existing tracing
+// header convention (e.g. "X-B3-TraceId"). Defaults to
+// DefaultTraceHeaderName.
+func WithTraceHeaderName(name string) ClientOption {
+	return func(c *Client) {
+		c.traceHeaderName = name
+	}
+}
+
+// WithPollIntervalBounds clamps the interval Poll waits between attempts to
+// [min, max], overriding a hive-advertised interval_ms that falls outside
+// that range instead of trusting it outright; a hive advertising an
+// aggressively short interval can otherwise be hammered with requests. A
+// zero min or max leaves that bound unset. Poll logs whenever it clamps the
+// advertised interval, so operators can tell the configured bounds are
+// actually being exercised.
+func WithPollIntervalBounds(min, max time.Duration) ClientOption {
+	return func(c *Client) {
+		c.minPollInterval = min
+		c.maxPollInterval = max
+	}
+}
+
+// WithMaxPollCount caps the number of attempts Poll makes before giving up
+// with ErrPollLimitExceeded, as a safety valve independent of its deadline
+// against a hive that never reaches DONE or ERROR. Non-positive leaves it
+// unbounded (the default).
+func WithMaxPollCount(maxPollCount int) ClientOption {
+	return func(c *Client) {
+		if maxPollCount > 0 {
+			c.maxPollCount = maxPollCount
+		}
+	}
+}
+
+// WithMaxResponseBytes caps how many bytes of an i2b2 response body are
+// buffered before unmarshalling, returning ErrResponseTooLarge instead of
+// reading further once a response exceeds the limit, so a misbehaving or
+// malicious hive cannot force an unbounded allocation. Non-positive leaves
+// responses unbounded (the default).
+func WithMaxResponseBytes(max int64) ClientOption {
+	return func(c *Client) {
+		if max > 0 {
+			c.maxResponseBytes = max
+		}
+	}
+}
+
+// WithCircuitBreaker short-circuits send with ErrCircuitOpen after
+// threshold consecutive failures, without attempting to reach the hive,
+// for cooldown, after which requests are let through again to test
+// whether it has recovered. A non-positive threshold leaves the breaker
+// disabled (the default), so an incoming Query against a down hive doesn't
+// amplify load and latency by retrying and timing out against it
+// repeatedly. See Client.CircuitBreakerState for observing the current
+// state, e.g. from a health check.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOption {
+	return func(c *Client) {
+		if threshold > 0 {
+			c.breaker = newCircuitBreaker(threshold, cooldown)
+		}
+	}
+}
+
+// WithDumpMessages enables logging the full outgoing request XML and raw
+// response body at debug level for every call, with the security password
+// field redacted. Intended as an opt-in debugging aid (see
+// i2b2.api.dump-messages) since hive exchanges can be verbose and, even
+// redacted, are not something to leave on by default in production.
+func WithDumpMessages(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.dumpMessages = enabled
+	}
+}
@@ -0,0 +1,53 @@
+package i2b2client
+
+import "regexp"
+
+// pathSeparatorRun matches one or more consecutive path separators,
+// forward or backward slash, so runs left behind by naive escaping (e.g.
+// "\\\\" for a single "\" once XML/JSON escaping is unwound) collapse to
+// one.
+var pathSeparatorRun = regexp.MustCompile(`[\\/]+`)
+
+// NormalizeConceptPath normalizes an i2b2 concept path (an ONT "key",
+// e.g. "\\i2b2\Diagnoses\A00-B99\") for comparison and caching:
+// forward slashes and repeated/escaped backslashes are collapsed to
+// i2b2's own single-backslash separator, and the result is wrapped in
+// exactly one leading and trailing separator. Paths from different i2b2
+// cells or transports that are otherwise equivalent normalize to the same
+// string, avoiding spurious cache misses and comparison bugs.
+func NormalizeConceptPath(path string) string {
+	path = trimSeparators(normalizeSeparators(path))
+	if path == "" {
+		return `\`
+	}
+	return `\` + path + `\`
+}
+
+// normalizeSeparators collapses runs of forward/backward slashes in path
+// to i2b2's own single backslash separator, without touching leading,
+// trailing, or otherwise absent separators. Used where a prefix match
+// (e.g. a modifier's applied_path wildcard) needs consistent separators
+// without NormalizeConceptPath's leading/trailing wrapping.
+func normalizeSeparators(path string) string {
+	return pathSeparatorRun.ReplaceAllString(path, `\`)
+}
+
+// ConceptPathsEqual reports whether a and b denote the same i2b2 concept
+// path once normalized (see NormalizeConceptPath), regardless of
+// separator style or leading/trailing slashes.
+func ConceptPathsEqual(a, b string) bool {
+	return NormalizeConceptPath(a) == NormalizeConceptPath(b)
+}
+
+// trimSeparators trims leading and trailing path separators (forward or
+// backward slash) from path.
+func trimSeparators(path string) string {
+	start, end := 0, len(path)
+	for start < end && (path[start] == '\\' || path[start] == '/') {
+		start++
+	}
+	for end > start && (path[end-1] == '\\' || path[end-1] == '/') {
+		end--
+	}
+	return path[start:end]
+}
@@ -0,0 +1,47 @@
+package i2b2client
+
+import "testing"
+
+func TestNormalizeConceptPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"already normalized", `\i2b2\Diagnoses\A00-B99\`, `\i2b2\Diagnoses\A00-B99\`},
+		{"missing leading and trailing slash", `i2b2\Diagnoses\A00-B99`, `\i2b2\Diagnoses\A00-B99\`},
+		{"forward slashes", `/i2b2/Diagnoses/A00-B99/`, `\i2b2\Diagnoses\A00-B99\`},
+		{"double-escaped backslashes", `\\i2b2\\Diagnoses\\A00-B99\\`, `\i2b2\Diagnoses\A00-B99\`},
+		{"mixed separators", `\i2b2/Diagnoses\\A00-B99/`, `\i2b2\Diagnoses\A00-B99\`},
+		{"root", `\`, `\`},
+		{"empty", "", `\`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeConceptPath(tt.path); got != tt.want {
+				t.Errorf("NormalizeConceptPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConceptPathsEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"identical", `\i2b2\Diagnoses\`, `\i2b2\Diagnoses\`, true},
+		{"forward vs backward slash", `/i2b2/Diagnoses/`, `\i2b2\Diagnoses\`, true},
+		{"double-escaped vs normal", `\\i2b2\\Diagnoses\\`, `\i2b2\Diagnoses\`, true},
+		{"missing trailing slash", `\i2b2\Diagnoses`, `\i2b2\Diagnoses\`, true},
+		{"different concepts", `\i2b2\Diagnoses\`, `\i2b2\Procedures\`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConceptPathsEqual(tt.a, tt.b); got != tt.want {
+				t.Errorf("ConceptPathsEqual(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package i2b2client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PatientDemographics is the subset of a CRC PDO patient_dimension record
+// relevant to explore query demographic output.
+type PatientDemographics struct {
+	PatientID   string `xml:"patient_id"`
+	Age         string `xml:"age_in_years_num"`
+	Sex         string `xml:"sex_cd"`
+	VitalStatus string `xml:"vital_status_cd"`
+}
+
+type pdoResponse struct {
+	Response
+	Body struct {
+		Patients []PatientDemographics `xml:"patient"`
+	} `xml:"message_body"`
+}
+
+// GetPatientDemographics fetches patient_dimension attributes for every
+// patient in patientSetID via the CRC cell's getPDO_fromInputList request.
+func (c *Client) GetPatientDemographics(ctx context.Context, patientSetID string) ([]PatientDemographics, error) {
+	req := NewRequest([]byte(buildGetPDOFromInputListMessageBody(patientSetID)))
+
+	var resp pdoResponse
+	if err := c.sendChecked(ctx, c.crcCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: GetPatientDemographics: %w", err)
+	}
+	return resp.Body.Patients, nil
+}
+
+// ObservationFact is a single i2b2 observation_fact record returned by a
+// PDO request, with its raw valtype_cd/nval_num/tval_char/valueflag_cd
+// fields; call Value to parse them into a typed FactValue.
+type ObservationFact struct {
+	PatientID   string `xml:"patient_id"`
+	ConceptCD   string `xml:"concept_cd"`
+	StartDate   string `xml:"start_date"`
+	ValTypeCD   string `xml:"valtype_cd"`
+	TValChar    string `xml:"tval_char"`
+	NValNum     string `xml:"nval_num"`
+	UnitsCD     string `xml:"units_cd"`
+	ValueFlagCD string `xml:"valueflag_cd"`
+}
+
+// ValueFlag mirrors i2b2's valueflag_cd, flagging a fact's value as
+// abnormal, out of range, etc.; ValueFlagNone means no flag.
+type ValueFlag string
+
+// Recognized valueflag_cd values. i2b2 hives commonly use "@" rather than
+// an empty string for "no flag"; normalizeValueFlag treats both as
+// ValueFlagNone.
+const (
+	ValueFlagNone     ValueFlag = ""
+	ValueFlagLow      ValueFlag = "L"
+	ValueFlagHigh     ValueFlag = "H"
+	ValueFlagAbnormal ValueFlag = "A"
+)
+
+// normalizeValueFlag maps the raw valueflag_cd to a ValueFlag, collapsing
+// i2b2's "@" placeholder to ValueFlagNone.
+func normalizeValueFlag(raw string) ValueFlag {
+	if raw == "" || raw == "@" {
+		return ValueFlagNone
+	}
+	return ValueFlag(raw)
+}
+
+// FactValue is an ObservationFact's value, typed according to its
+// ValTypeCD: IsNumeric is true and Numeric/Units are populated for "N"
+// facts, Text is populated for "T" facts; any other ValTypeCD (i2b2 blob
+// types such as "D" or "B", which carry no nval_num/tval_char) leaves both
+// zero.
+type FactValue struct {
+	IsNumeric bool
+	Numeric   float64
+	Units     string
+	Text      string
+	Flag      ValueFlag
+}
+
+// Value parses f's raw fields into a typed FactValue, returning an error if
+// f.ValTypeCD is "N" but f.NValNum isn't a valid number.
+func (f ObservationFact) Value() (FactValue, error) {
+	value := FactValue{Flag: normalizeValueFlag(f.ValueFlagCD)}
+	switch f.ValTypeCD {
+	case "N":
+		n, err := strconv.ParseFloat(strings.TrimSpace(f.NValNum), 64)
+		if err != nil {
+			return FactValue{}, fmt.Errorf("i2b2client: parsing nval_num %q: %w", f.NValNum, err)
+		}
+		value.IsNumeric = true
+		value.Numeric = n
+		value.Units = f.UnitsCD
+	case "T":
+		value.Text = f.TValChar
+	}
+	return value, nil
+}
+
+type pdoObservationFactResponse struct {
+	Response
+	Body struct {
+		Facts []ObservationFact `xml:"observation_fact"`
+	} `xml:"message_body"`
+}
+
+// GetObservationFacts fetches observation_fact records (one per
+// patient/concept/instance) for every patient in patientSetID via the CRC
+// cell's getPDO_fromInputList request, with clinical-data output enabled.
+// Unlike GetPatientDemographics, facts are not parsed into typed values
+// here; call ObservationFact.Value on each as needed.
+func (c *Client) GetObservationFacts(ctx context.Context, patientSetID string) ([]ObservationFact, error) {
+	req := NewRequest([]byte(buildGetPDOFromInputListObservationsMessageBody(patientSetID)))
+
+	var resp pdoObservationFactResponse
+	if err := c.sendChecked(ctx, c.crcCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: GetObservationFacts: %w", err)
+	}
+	return resp.Body.Facts, nil
+}
+
+// buildGetPDOFromInputListObservationsMessageBody builds the CRC PSM
+// <getPDO_fromInputList> message body requesting observation_fact output
+// for the given patient set.
+func buildGetPDOFromInputListObservationsMessageBody(patientSetID string) string {
+	return fmt.Sprintf(`<crcpsmns:getPDO_fromInputList xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/">
+      <input_list>
+        <patient_set_coll_id>%s</patient_set_coll_id>
+      </input_list>
+      <output_option>
+        <patient_selection>
+          <select_statement>observation_fact</select_statement>
+        </patient_selection>
+      </output_option>
+    </crcpsmns:getPDO_fromInputList>`, escapeXML(patientSetID))
+}
+
+// buildGetPDOFromInputListMessageBody builds the CRC PSM
+// <getPDO_fromInputList> message body requesting patient_dimension output
+// for the given patient set.
+func buildGetPDOFromInputListMessageBody(patientSetID string) string {
+	return fmt.Sprintf(`<crcpsmns:getPDO_fromInputList xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/">
+      <input_list>
+        <patient_set_coll_id>%s</patient_set_coll_id>
+      </input_list>
+      <output_option>
+        <patient_selection>
+          <select_statement>patient_dimension</select_statement>
+        </patient_selection>
+      </output_option>
+    </crcpsmns:getPDO_fromInputList>`, escapeXML(patientSetID))
+}
@@ -0,0 +1,50 @@
+package i2b2client
+
+import "testing"
+
+func TestObservationFactValue(t *testing.T) {
+	tests := []struct {
+		name string
+		fact ObservationFact
+		want FactValue
+	}{
+		{
+			"numeric",
+			ObservationFact{ValTypeCD: "N", NValNum: "42.5", UnitsCD: "mg/dL", ValueFlagCD: "H"},
+			FactValue{IsNumeric: true, Numeric: 42.5, Units: "mg/dL", Flag: ValueFlagHigh},
+		},
+		{
+			"text",
+			ObservationFact{ValTypeCD: "T", TValChar: "positive"},
+			FactValue{Text: "positive"},
+		},
+		{
+			"blob valtype has no value",
+			ObservationFact{ValTypeCD: "D", TValChar: "ignored", NValNum: "ignored"},
+			FactValue{},
+		},
+		{
+			"no-flag placeholder normalizes to ValueFlagNone",
+			ObservationFact{ValTypeCD: "T", TValChar: "x", ValueFlagCD: "@"},
+			FactValue{Text: "x", Flag: ValueFlagNone},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fact.Value()
+			if err != nil {
+				t.Fatalf("Value() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Value() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObservationFactValueInvalidNumeric(t *testing.T) {
+	_, err := ObservationFact{ValTypeCD: "N", NValNum: "not-a-number"}.Value()
+	if err == nil {
+		t.Fatal("Value() error = nil, want error for invalid nval_num")
+	}
+}
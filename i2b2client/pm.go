@@ -0,0 +1,64 @@
+package i2b2client
+
+import (
+	"context"
+	"fmt"
+)
+
+// UserConfiguration is the subset of the PM cell's getUserConfiguration
+// response we currently use.
+type UserConfiguration struct {
+	Projects []struct {
+		ID   string `xml:"id,attr"`
+		Name string `xml:"name"`
+		Role string `xml:"role"`
+	} `xml:"project"`
+
+	// HiveVersion is the i2b2_version the PM cell reports for the hive,
+	// used by NegotiateVersion to detect a mismatch with the configured
+	// ConnectionInfo.VersionCompatible.
+	HiveVersion string `xml:"hive_version"`
+}
+
+type getUserConfigurationResponse struct {
+	Response
+	Body struct {
+		Configuration UserConfiguration `xml:"configure>user"`
+	} `xml:"message_body"`
+}
+
+// GetUserConfiguration calls the PM cell's getUserConfiguration request for
+// the configured user, confirming credentials and hive reachability.
+func (c *Client) GetUserConfiguration(ctx context.Context) (*UserConfiguration, error) {
+	req := NewRequest([]byte(`<pm:getUserConfiguration xmlns:pm="http://www.i2b2.org/xsd/cell/pm/1.1/"/>`))
+
+	var resp getUserConfigurationResponse
+	if err := c.sendChecked(ctx, c.pmCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: GetUserConfiguration: %w", err)
+	}
+	return &resp.Body.Configuration, nil
+}
+
+// Ping issues a lightweight PM cell call to confirm credentials are valid
+// and the hive is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.GetUserConfiguration(ctx)
+	return err
+}
+
+// NegotiateVersion queries the PM cell for the hive's reported version and
+// logs a warning when it differs from the configured
+// ConnectionInfo.VersionCompatible, so a hive upgrade that drifts from our
+// configured compatibility version is noticed rather than silently
+// tolerated. It returns the hive's reported version regardless of whether
+// it matches.
+func (c *Client) NegotiateVersion(ctx context.Context) (string, error) {
+	userConfig, err := c.GetUserConfiguration(ctx)
+	if err != nil {
+		return "", fmt.Errorf("i2b2client: NegotiateVersion: %w", err)
+	}
+	if userConfig.HiveVersion != "" && userConfig.HiveVersion != c.conn.VersionCompatible {
+		loggerFromContext(ctx).Warnf("i2b2 hive reports version %q, configured i2b2_version_compatible is %q", userConfig.HiveVersion, c.conn.VersionCompatible)
+	}
+	return userConfig.HiveVersion, nil
+}
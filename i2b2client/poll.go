@@ -0,0 +1,105 @@
+package i2b2client
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// ErrPollTimeout is returned by Poll when the deadline elapses before the
+// polled result reaches a DONE status.
+var ErrPollTimeout = fmt.Errorf("i2b2client: timed out waiting for result")
+
+// ErrPollLimitExceeded is returned by Poll when c.maxPollCount attempts
+// have been made without the result reaching DONE or an error status, as a
+// safety valve independent of the deadline against a hive that never stops
+// reporting PROCESSING.
+var ErrPollLimitExceeded = fmt.Errorf("i2b2client: exceeded maximum poll attempts")
+
+// Poll repeatedly GETs poll.URL at the interval it advertises (clamped to
+// [c.minPollInterval, c.maxPollInterval] when those are set, see
+// WithPollIntervalBounds) until the response status becomes DONE, the
+// response reports an error, ctx is cancelled, deadline elapses, or
+// c.maxPollCount attempts have been made (see WithMaxPollCount). If
+// deadline is zero, c.conn.WaitTime is used. out, if non-nil, receives the
+// decoded final response body.
+func (c *Client) Poll(ctx context.Context, poll *PollingURL, deadline time.Duration, out interface{}) (*Response, error) {
+	if poll == nil {
+		return nil, fmt.Errorf("i2b2client: Poll called with no polling URL")
+	}
+	if deadline <= 0 {
+		deadline = c.conn.WaitTime
+	}
+	interval := time.Duration(poll.IntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if c.minPollInterval > 0 && interval < c.minPollInterval {
+		loggerFromContext(ctx).Debugf("i2b2client: hive advertised poll interval %s is below the configured minimum %s, clamping", interval, c.minPollInterval)
+		interval = c.minPollInterval
+	}
+	if c.maxPollInterval > 0 && interval > c.maxPollInterval {
+		loggerFromContext(ctx).Debugf("i2b2client: hive advertised poll interval %s exceeds the configured maximum %s, clamping", interval, c.maxPollInterval)
+		interval = c.maxPollInterval
+	}
+
+	deadlineAt := now().Add(deadline)
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if c.maxPollCount > 0 && attempt > c.maxPollCount {
+			return nil, ErrPollLimitExceeded
+		}
+
+		raw, err := c.get(ctx, poll.URL)
+		if err != nil {
+			return nil, fmt.Errorf("i2b2client: polling %s: %w", poll.URL, err)
+		}
+
+		var resp Response
+		if err := xml.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("i2b2client: decoding poll response from %s: %w", poll.URL, err)
+		}
+
+		if statusErr := resp.CheckStatus(); statusErr == nil {
+			if out != nil {
+				if err := xml.Unmarshal(raw, out); err != nil {
+					return nil, fmt.Errorf("i2b2client: decoding poll result from %s: %w", poll.URL, err)
+				}
+			}
+			return &resp, nil
+		} else if !errors.Is(statusErr, ErrProcessing) {
+			return &resp, statusErr
+		}
+
+		if now().After(deadlineAt) {
+			return nil, ErrPollTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// get issues a GET request to url and returns the raw response body.
+func (c *Client) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,197 @@
+package i2b2client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// QueryResultInstance is one named result (a patient set, a count, ...)
+// produced by a QueryInstance, as returned by GetQueryResultInstances.
+type QueryResultInstance struct {
+	ResultInstanceID string
+	ResultTypeName   string
+	SetSize          int
+	Status           string
+}
+
+// QueryInstance is one run of a QueryMaster, nesting the result instances
+// (patient set, counts, ...) it produced, as returned by
+// GetQueryResultInstances.
+type QueryInstance struct {
+	QueryInstanceID string
+	StartDate       string
+	Status          string
+	ResultInstances []QueryResultInstance
+}
+
+// QueryMaster is a previously submitted query definition, as returned by
+// ListQueryMasters. Status reflects its most recent run, or "" if it was
+// never run.
+type QueryMaster struct {
+	QueryMasterID string
+	Name          string
+	CreateDate    string
+	Status        string
+}
+
+type queryMasterListResponse struct {
+	Response
+	Body struct {
+		QueryMasters []struct {
+			QueryMasterID  string `xml:"query_master_id"`
+			Name           string `xml:"name"`
+			CreateDate     string `xml:"create_date"`
+			QueryInstances []struct {
+				StatusType struct {
+					Name string `xml:"name"`
+				} `xml:"query_status_type"`
+			} `xml:"query_instance"`
+		} `xml:"query_master"`
+	} `xml:"message_body"`
+}
+
+// ListQueryMasters lists the configured user's previously submitted query
+// masters (definitions), via the CRC cell's getQueryMasterList request.
+// Lets a UI show query history without re-running anything.
+func (c *Client) ListQueryMasters(ctx context.Context) ([]QueryMaster, error) {
+	req := NewRequest([]byte(buildGetQueryMasterListMessageBody()))
+
+	var resp queryMasterListResponse
+	if err := c.sendChecked(ctx, c.crcCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: ListQueryMasters: %w", err)
+	}
+
+	masters := make([]QueryMaster, len(resp.Body.QueryMasters))
+	for i, m := range resp.Body.QueryMasters {
+		master := QueryMaster{
+			QueryMasterID: m.QueryMasterID,
+			Name:          m.Name,
+			CreateDate:    m.CreateDate,
+		}
+		if n := len(m.QueryInstances); n > 0 {
+			master.Status = m.QueryInstances[n-1].StatusType.Name
+		}
+		masters[i] = master
+	}
+	return masters, nil
+}
+
+type queryResultInstanceListResponse struct {
+	Response
+	Body struct {
+		QueryInstances []struct {
+			QueryInstanceID string `xml:"query_instance_id"`
+			StartDate       string `xml:"start_date"`
+			StatusType      struct {
+				Name string `xml:"name"`
+			} `xml:"query_status_type"`
+			ResultInstances []struct {
+				ResultInstanceID string `xml:"result_instance_id"`
+				ResultTypeName   string `xml:"query_result_type>name"`
+				SetSize          int    `xml:"set_size"`
+				StatusType       struct {
+					Name string `xml:"name"`
+				} `xml:"query_status_type"`
+			} `xml:"query_result_instance"`
+		} `xml:"query_instance"`
+	} `xml:"message_body"`
+}
+
+// GetQueryResultInstances fetches queryMasterID's query instances (runs)
+// and, nested inside each, its result instances (patient sets, counts,
+// ...), via the CRC cell's getQueryResultInstanceList request.
+func (c *Client) GetQueryResultInstances(ctx context.Context, queryMasterID string) ([]QueryInstance, error) {
+	req := NewRequest([]byte(buildGetQueryResultInstanceListMessageBody(queryMasterID)))
+
+	var resp queryResultInstanceListResponse
+	if err := c.sendChecked(ctx, c.crcCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: GetQueryResultInstances: %w", err)
+	}
+
+	instances := make([]QueryInstance, len(resp.Body.QueryInstances))
+	for i, qi := range resp.Body.QueryInstances {
+		results := make([]QueryResultInstance, len(qi.ResultInstances))
+		for j, r := range qi.ResultInstances {
+			results[j] = QueryResultInstance{
+				ResultInstanceID: r.ResultInstanceID,
+				ResultTypeName:   r.ResultTypeName,
+				SetSize:          r.SetSize,
+				Status:           r.StatusType.Name,
+			}
+		}
+		instances[i] = QueryInstance{
+			QueryInstanceID: qi.QueryInstanceID,
+			StartDate:       qi.StartDate,
+			Status:          qi.StatusType.Name,
+			ResultInstances: results,
+		}
+	}
+	return instances, nil
+}
+
+// RenameQueryMaster renames the query master identified by queryMasterID
+// to newName via the CRC cell's renameQueryMaster request, and returns the
+// updated master. newName must be non-empty. A hive rejection because
+// another master already uses newName surfaces as an error satisfying
+// errors.Is(err, ErrDuplicateName).
+func (c *Client) RenameQueryMaster(ctx context.Context, queryMasterID string, newName string) (*QueryMaster, error) {
+	if newName == "" {
+		return nil, fmt.Errorf("i2b2client: RenameQueryMaster requires a non-empty newName")
+	}
+
+	req := NewRequest([]byte(buildRenameQueryMasterMessageBody(queryMasterID, newName)))
+
+	var resp Response
+	if err := c.send(ctx, c.crcCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: RenameQueryMaster: %w", err)
+	}
+	if err := resp.CheckStatus(); err != nil {
+		if isDuplicateName(err) {
+			return nil, fmt.Errorf("i2b2client: RenameQueryMaster: %w", classify(ErrDuplicateName, err))
+		}
+		return nil, fmt.Errorf("i2b2client: RenameQueryMaster: %w", err)
+	}
+
+	return &QueryMaster{QueryMasterID: queryMasterID, Name: newName}, nil
+}
+
+// isDuplicateName reports whether err indicates the hive rejected a rename
+// because another query master already uses the requested name.
+func isDuplicateName(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	lower := strings.ToLower(statusErr.Text)
+	return strings.Contains(lower, "duplicate") || strings.Contains(lower, "already exists") || strings.Contains(lower, "already in use")
+}
+
+// buildRenameQueryMasterMessageBody builds the CRC PSM <psmheader> message
+// body for renaming queryMasterID to newName.
+func buildRenameQueryMasterMessageBody(queryMasterID string, newName string) string {
+	return fmt.Sprintf(`<crcpsmns:psmheader xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/">
+      <request_type>CRC_QRY_renameQueryMaster</request_type>
+      <query_master_id>%s</query_master_id>
+      <new_name>%s</new_name>
+    </crcpsmns:psmheader>`, escapeXML(queryMasterID), escapeXML(newName))
+}
+
+// buildGetQueryMasterListMessageBody builds the CRC PSM <psmheader> message
+// body for listing the configured user's query masters.
+func buildGetQueryMasterListMessageBody() string {
+	return `<crcpsmns:psmheader xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/">
+      <request_type>CRC_QRY_getQueryMasterList</request_type>
+    </crcpsmns:psmheader>`
+}
+
+// buildGetQueryResultInstanceListMessageBody builds the CRC PSM
+// <psmheader> message body for listing queryMasterID's query instances and
+// their result instances.
+func buildGetQueryResultInstanceListMessageBody(queryMasterID string) string {
+	return fmt.Sprintf(`<crcpsmns:psmheader xmlns:crcpsmns="http://www.i2b2.org/xsd/cell/crc/psm/1.1/">
+      <request_type>CRC_QRY_getQueryResultInstanceList</request_type>
+      <query_master_id>%s</query_master_id>
+    </crcpsmns:psmheader>`, escapeXML(queryMasterID))
+}
@@ -0,0 +1,201 @@
+package i2b2client
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// now is overridable in tests so request timestamps are deterministic.
+var now = time.Now
+
+// messageCounter is incremented for every request built by NewRequest, so
+// MessageControlIDMessageNum/MessageControlIDSessionID stay unique even
+// across concurrent calls within the same now() tick, which a
+// timestamp-derived ID would otherwise collide on.
+var messageCounter uint64
+
+// nextMessageControlID returns a fresh, process-wide unique ID for a
+// message control field.
+func nextMessageControlID() string {
+	return strconv.FormatUint(atomic.AddUint64(&messageCounter, 1), 10)
+}
+
+// MessageHeader is the i2b2 <message_header> block sent with every request.
+// Field names flatten the nested XML elements they populate.
+type MessageHeader struct {
+	SendingApplicationApplicationName      string `xml:"sending_application>application_name"`
+	SendingApplicationApplicationVersion   string `xml:"sending_application>application_version"`
+	ReceivingApplicationApplicationName    string `xml:"receiving_application>application_name"`
+	ReceivingApplicationApplicationVersion string `xml:"receiving_application>application_version"`
+
+	DatetimeOfMessage string `xml:"datetime_of_message"`
+
+	SecurityDomain   string `xml:"security>domain"`
+	SecurityUsername string `xml:"security>username"`
+	SecurityPassword string `xml:"security>password"`
+
+	// MessageControlIDMessageNum and MessageControlIDSessionID identify this
+	// request for hive-side correlation and dedup.
+	MessageControlIDMessageNum string `xml:"message_control_id>message_num"`
+	MessageControlIDSessionID  string `xml:"message_control_id>instance_num"`
+
+	ProcessingIDProcessingID   string `xml:"processing_id>processing_id"`
+	ProcessingIDProcessingMode string `xml:"processing_id>processing_mode"`
+
+	I2b2VersionCompatible string `xml:"i2b2_version_compatible"`
+
+	ProjectID string `xml:"project_id"`
+
+	// ExtraFields holds additional <message_header> child elements for i2b2
+	// extensions or proxies that require header fields this struct doesn't
+	// model natively. Each element carries its own XMLName, so it marshals
+	// under the given tag regardless of this field's own name; nil leaves
+	// the header unchanged. Elements are emitted in slice order, after all
+	// fields above, so output stays deterministic rather than depending on
+	// map iteration order. See (*Request).AddExtraHeaderField.
+	ExtraFields []ExtraHeaderField
+}
+
+// ExtraHeaderField is a single additional <message_header> child element,
+// for i2b2 extensions or proxies that require header fields MessageHeader
+// doesn't model. See (*Request).AddExtraHeaderField.
+type ExtraHeaderField struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// String implements fmt.Stringer with the security password redacted, so
+// that %v/%s-formatting a MessageHeader (e.g. in an error message or an ad
+// hoc debug log) can never leak the credential in plaintext.
+func (h MessageHeader) String() string {
+	if h.SecurityPassword != "" {
+		h.SecurityPassword = "***"
+	}
+	// MessageControlIDSessionID doubles as the session token once a request
+	// is authenticated (see Client.sendAttempt), so it is just as sensitive
+	// as SecurityPassword and must be redacted the same way.
+	if h.MessageControlIDSessionID != "" {
+		h.MessageControlIDSessionID = "***"
+	}
+	return fmt.Sprintf("{SendingApplication:%s ReceivingApplication:%s/%s Security:%s/%s/*** MessageControlID:%s/*** ProjectID:%s I2b2VersionCompatible:%s}",
+		h.SendingApplicationApplicationName,
+		h.ReceivingApplicationApplicationName, h.ReceivingApplicationApplicationVersion,
+		h.SecurityDomain, h.SecurityUsername,
+		h.MessageControlIDMessageNum,
+		h.ProjectID, h.I2b2VersionCompatible,
+	)
+}
+
+// RequestHeader is the i2b2 <request_header> block.
+type RequestHeader struct {
+	ResultWaittimeMs string `xml:"result_waittime_ms,omitempty"`
+}
+
+// Request is the generic i2b2 <request> envelope. MessageBody carries the
+// cell-specific payload as already-serialized XML.
+type Request struct {
+	XMLName       xml.Name      `xml:"request"`
+	MessageHeader MessageHeader `xml:"message_header"`
+	RequestHeader RequestHeader `xml:"request_header"`
+	MessageBody   MessageBody   `xml:"message_body"`
+
+	// projectIDOverride, when set via OverrideProjectID, takes precedence
+	// over ConnectionInfo.ProjectID for this request only. It is unexported
+	// so it never appears in the marshaled XML.
+	projectIDOverride string
+
+	// sentAt is the time NewRequest captured, kept so SetConnectionInfo can
+	// render MessageHeader.DatetimeOfMessage using the client's configured
+	// DateTimeFormat/TimeZone instead of the RFC3339/UTC NewRequest defaults
+	// to before a ConnectionInfo is known.
+	sentAt time.Time
+}
+
+// OverrideProjectID scopes this request to projectID instead of the
+// client's configured ConnectionInfo.ProjectID, without mutating any
+// shared client state. A later call to SetConnectionInfo (including the
+// one send performs internally on retry) still honors the override.
+func (r *Request) OverrideProjectID(projectID string) {
+	r.projectIDOverride = projectID
+}
+
+// AddExtraHeaderField appends an additional <message_header> child element
+// named name with the given value, for i2b2 extensions or proxies that
+// require header fields MessageHeader doesn't model natively (e.g. a
+// reverse proxy injecting routing metadata). Fields are serialized in the
+// order added, after the fixed fields, keeping output deterministic. Like
+// OverrideProjectID, an extra field survives the request across send's
+// transparent re-login retry.
+func (r *Request) AddExtraHeaderField(name, value string) {
+	r.MessageHeader.ExtraFields = append(r.MessageHeader.ExtraFields, ExtraHeaderField{
+		XMLName: xml.Name{Local: name},
+		Value:   value,
+	})
+}
+
+// MessageBody wraps a cell-specific request body that has already been
+// rendered to XML.
+type MessageBody struct {
+	InnerXML []byte `xml:",innerxml"`
+}
+
+// NewRequest builds a Request envelope for the given cell-specific body,
+// stamping the current time and a fresh message control ID.
+func NewRequest(body []byte) *Request {
+	t := now()
+	return &Request{
+		MessageHeader: MessageHeader{
+			SendingApplicationApplicationName: "GeCo",
+			DatetimeOfMessage:                 t.Format(time.RFC3339),
+			MessageControlIDMessageNum:        nextMessageControlID(),
+			MessageControlIDSessionID:         nextMessageControlID(),
+		},
+		MessageBody: MessageBody{InnerXML: body},
+		sentAt:      t,
+	}
+}
+
+// SetConnectionInfo fills in the security block and result wait time of the
+// request from conn, and re-renders DatetimeOfMessage in conn's configured
+// DateTimeFormat/TimeZone instead of the RFC3339/UTC NewRequest used before
+// a ConnectionInfo was known.
+func (r *Request) SetConnectionInfo(conn ConnectionInfo) {
+	r.MessageHeader.SecurityDomain = conn.Domain
+	r.MessageHeader.SecurityUsername = conn.Username
+	r.MessageHeader.SecurityPassword = conn.Password
+	r.MessageHeader.ReceivingApplicationApplicationVersion = conn.ApplicationVersion
+	r.MessageHeader.I2b2VersionCompatible = conn.VersionCompatible
+	r.MessageHeader.ProjectID = conn.ProjectID
+	if r.projectIDOverride != "" {
+		r.MessageHeader.ProjectID = r.projectIDOverride
+	}
+	r.MessageHeader.ProcessingIDProcessingID = conn.ProcessingID
+	r.MessageHeader.ProcessingIDProcessingMode = conn.ProcessingMode
+	r.RequestHeader.ResultWaittimeMs = strconv.FormatInt(conn.WaitTime.Milliseconds(), 10)
+	if conn.DateTimeFormat != "" {
+		tz := conn.TimeZone
+		if tz == nil {
+			tz = time.UTC
+		}
+		r.MessageHeader.DatetimeOfMessage = r.sentAt.In(tz).Format(conn.DateTimeFormat)
+	}
+}
+
+// Preview renders this request's XML exactly as send would serialize it
+// before transmission, without contacting the hive, redacting the security
+// password and session token the same way MessageHeader.String does so the
+// returned XML is safe to log or hand back to a caller for audit/debugging.
+func (r *Request) Preview(conn ConnectionInfo) ([]byte, error) {
+	r.SetConnectionInfo(conn)
+	redacted := *r
+	if redacted.MessageHeader.SecurityPassword != "" {
+		redacted.MessageHeader.SecurityPassword = "***"
+	}
+	if redacted.MessageHeader.MessageControlIDSessionID != "" {
+		redacted.MessageHeader.MessageControlIDSessionID = "***"
+	}
+	return xml.Marshal(redacted)
+}
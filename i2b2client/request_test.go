@@ -0,0 +1,112 @@
+package i2b2client
+
+import (
+	"encoding/xml"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestNewRequestDatetimeOfMessagePinned overrides the package-level now
+// clock to assert NewRequest's DatetimeOfMessage against an exact,
+// reproducible value, rather than merely checking it parses.
+func TestNewRequestDatetimeOfMessagePinned(t *testing.T) {
+	originalNow := now
+	defer func() { now = originalNow }()
+	pinned := time.Date(2024, time.March, 2, 15, 4, 5, 0, time.UTC)
+	now = func() time.Time { return pinned }
+
+	req := NewRequest(nil)
+
+	want := "2024-03-02T15:04:05Z"
+	if got := req.MessageHeader.DatetimeOfMessage; got != want {
+		t.Fatalf("DatetimeOfMessage = %q, want %q", got, want)
+	}
+}
+
+// TestRequestPreviewRedactsCredentials confirms Preview never leaks the
+// security password or session token into its returned XML, since callers
+// use Preview's output for audit logging and debugging where the raw
+// request is not appropriate.
+func TestRequestPreviewRedactsCredentials(t *testing.T) {
+	req := NewRequest([]byte(`<foo/>`))
+	xmlBytes, err := req.Preview(ConnectionInfo{
+		Domain:    "dom",
+		Username:  "alice",
+		Password:  "hunter2",
+		ProjectID: "proj",
+	})
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	got := string(xmlBytes)
+	if strings.Contains(got, "hunter2") {
+		t.Fatalf("Preview XML leaked the password: %s", got)
+	}
+	if !strings.Contains(got, "***") {
+		t.Fatalf("Preview XML did not redact the password/session token: %s", got)
+	}
+	if !strings.Contains(got, "alice") {
+		t.Fatalf("Preview XML unexpectedly dropped the non-sensitive username: %s", got)
+	}
+}
+
+// TestRequestAddExtraHeaderField asserts extra header fields marshal as
+// their own named elements inside message_header, in the order added,
+// without disturbing the fixed fields around them.
+func TestRequestAddExtraHeaderField(t *testing.T) {
+	req := NewRequest([]byte(`<foo/>`))
+	req.AddExtraHeaderField("proxy_route", "site-a")
+	req.AddExtraHeaderField("proxy_tenant", "tenant-1")
+	req.SetConnectionInfo(ConnectionInfo{Domain: "dom", Username: "alice", Password: "hunter2"})
+
+	raw, err := xml.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got := string(raw)
+
+	routeIdx := strings.Index(got, "<proxy_route>site-a</proxy_route>")
+	tenantIdx := strings.Index(got, "<proxy_tenant>tenant-1</proxy_tenant>")
+	if routeIdx == -1 || tenantIdx == -1 {
+		t.Fatalf("extra header fields missing from marshaled XML: %s", got)
+	}
+	if routeIdx > tenantIdx {
+		t.Fatalf("extra header fields out of order, want proxy_route before proxy_tenant: %s", got)
+	}
+	if !strings.Contains(got, "<project_id></project_id>") {
+		t.Fatalf("extra header fields disturbed a neighboring fixed field: %s", got)
+	}
+}
+
+// TestNewRequestMessageControlIDsUnique builds many requests concurrently
+// and asserts every MessageControlIDMessageNum and
+// MessageControlIDSessionID is unique, guarding against the IDs
+// regressing to something timestamp-derived that two requests built in
+// the same tick could collide on.
+func TestNewRequestMessageControlIDsUnique(t *testing.T) {
+	const n = 1000
+
+	ids := make(chan string, n*2)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := NewRequest(nil)
+			ids <- req.MessageHeader.MessageControlIDMessageNum
+			ids <- req.MessageHeader.MessageControlIDSessionID
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate message control ID %q", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,94 @@
+package i2b2client
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// Condition is a single entry in an i2b2 result status's conditions list,
+// e.g. a warning surfaced alongside an otherwise usable DONE/WARNING body.
+type Condition struct {
+	Type         string `xml:"type,attr"`
+	CodingSystem string `xml:"coding_system,attr"`
+	Text         string `xml:",chardata"`
+}
+
+// ResponseHeader is the <response_header> block common to all i2b2 cell
+// responses.
+type ResponseHeader struct {
+	ResultStatus struct {
+		Status struct {
+			Type string `xml:"type,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"status"`
+		Conditions []Condition `xml:"conditions>condition"`
+	} `xml:"result_status"`
+	PollingURL *PollingURL `xml:"polling_url"`
+}
+
+// PollingURL describes where and how often to poll for a still-processing
+// result, as returned by the CRC cell for long-running queries.
+type PollingURL struct {
+	IntervalMs int    `xml:"interval_ms,attr"`
+	URL        string `xml:",chardata"`
+}
+
+// Response is a generic i2b2 cell response envelope. Cell-specific bodies
+// are unmarshalled separately from the raw XML as needed.
+type Response struct {
+	XMLName xml.Name       `xml:"response"`
+	Header  ResponseHeader `xml:"response_header"`
+}
+
+// StatusError is returned by CheckStatus when the hive reports a non-DONE
+// result status, carrying the raw status type and text so callers can
+// distinguish an auth failure from a malformed query from a timeout.
+type StatusError struct {
+	Type string
+	Text string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("i2b2client: status %s: %s", e.Type, e.Text)
+}
+
+// ErrProcessing is returned by CheckStatus when the hive reports a
+// PROCESSING status, signalling the caller should poll for completion
+// rather than treat the request as failed.
+var ErrProcessing = &StatusError{Type: "PROCESSING", Text: "result not ready"}
+
+// CheckStatus returns nil for a DONE status, ErrProcessing for a PROCESSING
+// status, and a *StatusError for anything else (including ERROR and
+// WARNING). A WARNING's body may still be usable; callers that care should
+// inspect the returned *StatusError's Type rather than treat it as fatal.
+func (r *Response) CheckStatus() error {
+	status := r.Header.ResultStatus.Status
+	switch status.Type {
+	case "DONE":
+		return nil
+	case "PROCESSING":
+		return ErrProcessing
+	default:
+		return &StatusError{Type: status.Type, Text: status.Text}
+	}
+}
+
+// Conditions returns the conditions list attached to the response's result
+// status, if any.
+func (r *Response) Conditions() []Condition {
+	return r.Header.ResultStatus.Conditions
+}
+
+// HasErrorCondition reports whether any condition attached to the response
+// has type "ERROR", for callers that want a quick check without inspecting
+// Conditions() themselves. A response can carry ERROR conditions alongside
+// an otherwise DONE or WARNING top-level status, so this is not implied by
+// CheckStatus succeeding.
+func (r *Response) HasErrorCondition() bool {
+	for _, c := range r.Conditions() {
+		if c.Type == "ERROR" {
+			return true
+		}
+	}
+	return false
+}
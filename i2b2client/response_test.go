@@ -0,0 +1,43 @@
+package i2b2client
+
+import "testing"
+
+// TestResponseHasErrorCondition covers HasErrorCondition's behavior across a
+// response with no conditions, one with only warnings, and one with an
+// ERROR condition mixed in among others.
+func TestResponseHasErrorCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []Condition
+		want       bool
+	}{
+		{
+			name:       "no conditions",
+			conditions: nil,
+			want:       false,
+		},
+		{
+			name:       "only warnings",
+			conditions: []Condition{{Type: "WARNING", Text: "OBFUSCATED"}},
+			want:       false,
+		},
+		{
+			name: "error among warnings",
+			conditions: []Condition{
+				{Type: "WARNING", Text: "OBFUSCATED"},
+				{Type: "ERROR", Text: "permission denied"},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Response{}
+			r.Header.ResultStatus.Conditions = tt.conditions
+			if got := r.HasErrorCondition(); got != tt.want {
+				t.Errorf("HasErrorCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package i2b2client
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// isRetryableStatus reports whether status is a server error (any 5xx) worth
+// retrying.
+func isRetryableStatus(status int) bool {
+	return status >= http.StatusInternalServerError && status < 600
+}
+
+// backoffDelay computes the exponential backoff delay for the given attempt
+// (1-indexed), jittered by up to +/-25% to avoid thundering-herd retries.
+func backoffDelay(baseDelay time.Duration, attempt int) time.Duration {
+	if baseDelay <= 0 {
+		baseDelay = 100 * time.Millisecond
+	}
+	delay := baseDelay << (attempt - 1)
+	jitter := time.Duration(rand.Int63n(int64(delay)/2+1)) - delay/4
+	return delay + jitter
+}
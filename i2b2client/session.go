@@ -0,0 +1,99 @@
+package i2b2client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionTTL bounds how long a cached session token is trusted before a
+// fresh login is forced, independent of any expiry the hive reports.
+const sessionTTL = 30 * time.Minute
+
+// ensureSession returns a valid session token, reusing a cached one if it
+// hasn't expired. Concurrent callers that race on an expired/missing token
+// share a single in-flight login rather than each triggering their own.
+func (c *Client) ensureSession(ctx context.Context) (string, error) {
+	c.sessionMu.Lock()
+	if c.sessionToken != "" && now().Before(c.sessionExpiry) {
+		token := c.sessionToken
+		c.sessionMu.Unlock()
+		return token, nil
+	}
+	if c.loginDone != nil {
+		done := c.loginDone
+		c.sessionMu.Unlock()
+		<-done
+		return c.ensureSession(ctx)
+	}
+	done := make(chan struct{})
+	c.loginDone = done
+	c.sessionMu.Unlock()
+
+	token, err := c.login(ctx)
+
+	c.sessionMu.Lock()
+	if err == nil {
+		c.sessionToken = token
+		c.sessionExpiry = now().Add(sessionTTL)
+	}
+	c.loginDone = nil
+	c.sessionMu.Unlock()
+	close(done)
+
+	return token, err
+}
+
+// invalidateSession discards the cached session token so the next request
+// triggers a fresh login.
+func (c *Client) invalidateSession() {
+	c.sessionMu.Lock()
+	c.sessionToken = ""
+	c.sessionMu.Unlock()
+}
+
+// login authenticates against the PM cell with the configured
+// username/password and returns a session token.
+func (c *Client) login(ctx context.Context) (string, error) {
+	req := NewRequest([]byte(`<pm:getSessionToken xmlns:pm="http://www.i2b2.org/xsd/cell/pm/1.1/"/>`))
+	req.SetConnectionInfo(c.conn)
+
+	var resp struct {
+		Response
+		Body struct {
+			SessionToken string `xml:"session_token"`
+		} `xml:"message_body"`
+	}
+	if err := c.send(ctx, c.pmCellURL, req, &resp); err != nil {
+		return "", fmt.Errorf("i2b2client: login: %w", err)
+	}
+	if err := resp.CheckStatus(); err != nil {
+		return "", fmt.Errorf("i2b2client: login: %w", classify(ErrAuthentication, err))
+	}
+	if resp.Body.SessionToken == "" {
+		return "", fmt.Errorf("i2b2client: login: %w", classify(ErrAuthentication, fmt.Errorf("hive returned no session token")))
+	}
+	return resp.Body.SessionToken, nil
+}
+
+// isSessionExpired reports whether err indicates the hive rejected the
+// session token as expired or invalid, so the caller should re-login and
+// retry rather than surface the failure.
+func isSessionExpired(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return strings.Contains(strings.ToLower(statusErr.Text), "session")
+}
+
+// sessionFields, embedded in Client, hold the cached PM session token.
+type sessionFields struct {
+	sessionMu     sync.Mutex
+	sessionToken  string
+	sessionExpiry time.Time
+	loginDone     chan struct{}
+}
@@ -0,0 +1,47 @@
+package i2b2client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// TLSConfig describes the mutual-TLS settings for connecting to an i2b2
+// hive behind a client-certificate-authenticated proxy or load balancer.
+type TLSConfig struct {
+	ClientCertFile     string
+	ClientKeyFile      string
+	CAFile             string
+	InsecureSkipVerify bool
+}
+
+// BuildTLSConfig loads cfg's client certificate and CA file, if set, and
+// returns a *tls.Config ready to use on an http.Transport. It fails fast
+// with a clear error if a cert file is unreadable or the key doesn't match
+// the certificate.
+func BuildTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+
+	if cfg.ClientCertFile != "" || cfg.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("i2b2client: loading client certificate %s/%s: %w", cfg.ClientCertFile, cfg.ClientKeyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CAFile != "" {
+		caCert, err := ioutil.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("i2b2client: reading CA file %s: %w", cfg.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("i2b2client: no valid certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
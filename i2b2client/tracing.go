@@ -0,0 +1,32 @@
+package i2b2client
+
+import "context"
+
+// traceIDContextKey is the unexported context key ContextWithTraceID stores
+// its value under, so a trace ID can flow through to Client.doPost without
+// widening every method signature to carry it explicitly.
+type traceIDContextKey struct{}
+
+// ContextWithTraceID returns a copy of ctx carrying traceID. Any i2b2client
+// request made with the returned context (or one derived from it) sends
+// traceID in the header named by WithTraceHeaderName (DefaultTraceHeaderName
+// if unset), letting an operator correlate a single GeCo operation's log
+// lines across both GeCo and the i2b2 hive's own access logs.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDContextKey{}, traceID)
+}
+
+// traceIDFromContext returns the trace ID attached by ContextWithTraceID, if
+// any.
+func traceIDFromContext(ctx context.Context) (string, bool) {
+	traceID, ok := ctx.Value(traceIDContextKey{}).(string)
+	return traceID, ok && traceID != ""
+}
+
+// TraceIDFromContext returns the trace ID previously attached with
+// ContextWithTraceID, if any. Exported so an embedding application (or the
+// i2b2datasource package) can confirm which correlation ID a given call is
+// tagged with, e.g. for its own logging.
+func TraceIDFromContext(ctx context.Context) (string, bool) {
+	return traceIDFromContext(ctx)
+}
@@ -0,0 +1,23 @@
+package i2b2client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTraceIDFromContext(t *testing.T) {
+	if _, ok := traceIDFromContext(context.Background()); ok {
+		t.Fatalf("expected no trace ID on a bare context")
+	}
+
+	ctx := ContextWithTraceID(context.Background(), "abc-123")
+	traceID, ok := traceIDFromContext(ctx)
+	if !ok || traceID != "abc-123" {
+		t.Fatalf("traceIDFromContext = %q, %v; want abc-123, true", traceID, ok)
+	}
+
+	ctx = ContextWithTraceID(context.Background(), "")
+	if _, ok := traceIDFromContext(ctx); ok {
+		t.Fatalf("expected an empty trace ID to be treated as absent")
+	}
+}
@@ -0,0 +1,35 @@
+package i2b2client
+
+import "strings"
+
+// VisualAttributes is the raw i2b2 visual attributes code attached to a
+// concept or modifier, e.g. "LAE" for an active, editable leaf: the first
+// character marks its kind (leaf vs folder/container), the second its
+// state (active/hidden), and the third whether it's a synonym or editable.
+type VisualAttributes string
+
+// IsLeaf reports whether the concept is a leaf term rather than a
+// container with children, based on the code's first character ('L' for
+// leaf, 'F'/'C' for a folder/container).
+func (a VisualAttributes) IsLeaf() bool {
+	return strings.HasPrefix(string(a), "L")
+}
+
+// IsHidden reports whether the concept is marked hidden (not to be shown
+// in a browsable tree), based on the code's second character ('H').
+func (a VisualAttributes) IsHidden() bool {
+	return len(a) > 1 && a[1] == 'H'
+}
+
+// IsSynonym reports whether the concept is a synonym/alias for another
+// concept rather than its primary entry, based on the code's third
+// character ('S').
+func (a VisualAttributes) IsSynonym() bool {
+	return len(a) > 2 && a[2] == 'S'
+}
+
+// IsEditable reports whether the code's third character marks the concept
+// editable ('E'), as opposed to synonym ('S') or blank.
+func (a VisualAttributes) IsEditable() bool {
+	return len(a) > 2 && a[2] == 'E'
+}
@@ -0,0 +1,36 @@
+package i2b2client
+
+import "testing"
+
+func TestVisualAttributes(t *testing.T) {
+	tests := []struct {
+		attrs      VisualAttributes
+		isLeaf     bool
+		isHidden   bool
+		isSynonym  bool
+		isEditable bool
+	}{
+		{"LAE", true, false, false, true},
+		{"FAE", false, false, false, true},
+		{"LHE", true, true, false, true},
+		{"LAS", true, false, true, false},
+		{"L", true, false, false, false},
+		{"", false, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.attrs), func(t *testing.T) {
+			if got := tt.attrs.IsLeaf(); got != tt.isLeaf {
+				t.Errorf("IsLeaf() = %v, want %v", got, tt.isLeaf)
+			}
+			if got := tt.attrs.IsHidden(); got != tt.isHidden {
+				t.Errorf("IsHidden() = %v, want %v", got, tt.isHidden)
+			}
+			if got := tt.attrs.IsSynonym(); got != tt.isSynonym {
+				t.Errorf("IsSynonym() = %v, want %v", got, tt.isSynonym)
+			}
+			if got := tt.attrs.IsEditable(); got != tt.isEditable {
+				t.Errorf("IsEditable() = %v, want %v", got, tt.isEditable)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package i2b2client
+
+import (
+	"context"
+	"fmt"
+)
+
+// WorkplaceItem is a single node in a user's WORK cell workplace tree: a
+// folder, or a saved query reference nested inside one. Path/ParentPath
+// identify a node and its containing folder ("" for a top-level node), the
+// way i2b2 uses index/parent_index elsewhere in the workplace XML.
+type WorkplaceItem struct {
+	Name       string `xml:"name"`
+	Path       string `xml:"index"`
+	ParentPath string `xml:"parent_index"`
+	IsFolder   bool   `xml:"is_folder"`
+}
+
+type getWorkplaceResponse struct {
+	Response
+	Body struct {
+		Items []WorkplaceItem `xml:"workplace>item"`
+	} `xml:"message_body"`
+}
+
+// GetWorkplaceFolders lists the configured user's workplace folders and the
+// items nested inside them (most commonly saved query references) via the
+// WORK cell's getWorkplace request, as a flat list; callers reconstruct the
+// tree from each item's Path/ParentPath.
+func (c *Client) GetWorkplaceFolders(ctx context.Context) ([]WorkplaceItem, error) {
+	req := NewRequest([]byte(buildGetWorkplaceMessageBody()))
+
+	var resp getWorkplaceResponse
+	if err := c.sendChecked(ctx, c.workCellURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("i2b2client: GetWorkplaceFolders: %w", err)
+	}
+	return resp.Body.Items, nil
+}
+
+// buildGetWorkplaceMessageBody builds the WORK cell's <work:getWorkplace>
+// message body for the configured user's active workplace.
+func buildGetWorkplaceMessageBody() string {
+	return `<work:getWorkplace xmlns:work="http://www.i2b2.org/xsd/cell/work/1.1/">
+      <state>active</state>
+    </work:getWorkplace>`
+}
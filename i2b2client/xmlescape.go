@@ -0,0 +1,22 @@
+package i2b2client
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// escapeXML escapes s for safe inclusion as XML character data between
+// tags (e.g. "<term>"+escapeXML(s)+"</term>"), the same way encoding/xml
+// would escape a struct field marshaled normally. Every message body
+// builder in this package assembles its XML by hand via fmt.Sprintf
+// rather than encoding/xml.Marshal, so caller-supplied values (search
+// terms, concept paths, patient/basecode lists, ...) must be escaped
+// explicitly before interpolation, or a value containing "<", "&", etc.
+// produces malformed XML or lets a crafted value inject sibling elements
+// into the request.
+func escapeXML(s string) string {
+	var b strings.Builder
+	// xml.EscapeText never returns an error for a strings.Builder target.
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
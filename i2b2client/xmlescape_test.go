@@ -0,0 +1,23 @@
+package i2b2client
+
+import "testing"
+
+func TestEscapeXML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text needs no escaping", "diabetes", "diabetes"},
+		{"ampersand", "Mother & Child", "Mother &amp; Child"},
+		{"angle brackets", "</term><term>injected", "&lt;/term&gt;&lt;term&gt;injected"},
+		{"quotes", `say "hi"`, "say &#34;hi&#34;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeXML(tt.in); got != tt.want {
+				t.Errorf("escapeXML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
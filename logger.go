@@ -0,0 +1,30 @@
+package i2b2datasource
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+// loggerContextKey is the unexported context key contextWithLogger stores
+// its entry under.
+type loggerContextKey struct{}
+
+// contextWithLogger returns a copy of ctx carrying entry, so handlers
+// invoked further down the call stack can log through a request-scoped
+// logger (see loggerFromContext) instead of the bare package logger.
+func contextWithLogger(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, entry)
+}
+
+// loggerFromContext returns the logger attached by contextWithLogger,
+// falling back to the bare package logger when ctx carries none, e.g. in
+// tests that call a handler directly rather than through Query.
+func loggerFromContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(loggerContextKey{}).(*logrus.Entry); ok {
+		return entry
+	}
+	return logrus.NewEntry(logger)
+}
@@ -0,0 +1,46 @@
+package i2b2datasource
+
+import "time"
+
+// Metrics receives operation outcome, i2b2 request latency, and database
+// latency observations, letting the embedding application route them to
+// whatever metrics backend (e.g. Prometheus) it uses without this package
+// depending on a specific library.
+type Metrics interface {
+	// ObserveOperation records that operation completed, successfully or
+	// not.
+	ObserveOperation(operation Operation, success bool)
+
+	// ObserveI2b2Latency records how long a request to cellURL took, and
+	// the error it ultimately failed with, if any.
+	ObserveI2b2Latency(cellURL string, duration time.Duration, err error)
+
+	// ObserveDBLatency records how long a Postgres call took.
+	ObserveDBLatency(duration time.Duration)
+}
+
+// noopMetrics is used until SetMetrics is called.
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveOperation(Operation, bool)                {}
+func (noopMetrics) ObserveI2b2Latency(string, time.Duration, error) {}
+func (noopMetrics) ObserveDBLatency(time.Duration)                  {}
+
+// i2b2ClientMetrics adapts a Metrics to the i2b2client.Metrics interface,
+// which uses the same observation but can't import this package (it would
+// be a circular import).
+type i2b2ClientMetrics struct{ m Metrics }
+
+func (a i2b2ClientMetrics) ObserveRequestLatency(cellURL string, duration time.Duration, err error) {
+	a.m.ObserveI2b2Latency(cellURL, duration, err)
+}
+
+// SetMetrics routes operation, i2b2 request latency, and database latency
+// observations to m instead of discarding them. Metrics can't be threaded
+// through the flat config map NewI2b2DataSource accepts, so the embedding
+// application calls this after construction.
+func (ds *I2b2DataSource) SetMetrics(m Metrics) {
+	ds.metrics = m
+	ds.db.metrics = m
+	ds.i2b2Client.SetMetrics(i2b2ClientMetrics{m})
+}
@@ -0,0 +1,53 @@
+package i2b2datasource
+
+import "github.com/ldsec/GeCo/gecosdk"
+
+// ToModel returns the embedded gecosdk.DataSourceModel so GeCo can persist
+// this data source's definition.
+func (ds I2b2DataSource) ToModel() gecosdk.DataSourceModel {
+	return ds.DataSourceModel
+}
+
+// FromModel reconstructs a live I2b2DataSource from a stored
+// gecosdk.DataSourceModel: it copies the model, re-parses the connection and
+// database configuration it holds, and re-initializes the i2b2 client and
+// Postgres handle accordingly.
+func (ds *I2b2DataSource) FromModel(model gecosdk.DataSourceModel) error {
+	i2b2Client, db, err := newHandlers(model.Config)
+	if err != nil {
+		return err
+	}
+
+	ontCacheTTL, err := parseOntCacheTTL(model.Config)
+	if err != nil {
+		return err
+	}
+	ontMaxElements, err := parseOntMaxElements(model.Config)
+	if err != nil {
+		return err
+	}
+
+	ds.DataSourceModel = model
+	ds.i2b2Client = i2b2Client
+	ds.db = db
+	ds.allowedDemographicFields = parseAllowedDemographicFields(model.Config)
+	ds.allowedFactFields = parseAllowedFactFields(model.Config)
+	maxObservationFacts, err := parseMaxObservationFacts(model.Config)
+	if err != nil {
+		return err
+	}
+	ds.maxObservationFacts = maxObservationFacts
+	ds.hardDeleteCohorts = model.Config["i2b2.api.hard-delete-cohorts"] == "true"
+	if ontCacheTTL > 0 {
+		ds.ontCache = newOntCache(ontCacheTTL, defaultOntCacheSize)
+	} else {
+		ds.ontCache = nil
+	}
+	ds.ontMaxElements = ontMaxElements
+	if ds.metrics == nil {
+		ds.metrics = noopMetrics{}
+	}
+	ds.db.metrics = ds.metrics
+	ds.i2b2Client.SetMetrics(i2b2ClientMetrics{ds.metrics})
+	return nil
+}
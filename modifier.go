@@ -0,0 +1,149 @@
+package i2b2datasource
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// SearchModifierHandler performs a free-text search across i2b2 ONT cell
+// modifiers applicable to a concept and returns matching term paths with
+// their metadata. "Applicable" is the applied_path intersection: the
+// hive's own result is filtered again to keep only modifiers whose
+// applied_path matches conceptPath exactly, or via the "@" (any concept)
+// or trailing-"%" (prefix) wildcards i2b2 uses for applied_path (see
+// i2b2client.ModifierTerm.appliesTo).
+//
+// Parameters:
+//   - searchString (string, required): the free-text term to search for.
+//   - conceptPath (string, required): the concept to search modifiers for.
+//   - limit (string, optional): caps the number of returned terms per page;
+//     defaults to, and is itself capped by, ds.ontMaxElements.
+//   - offset (string, optional): number of matching terms to skip, for
+//     fetching subsequent pages; defaults to 0.
+//
+// The result's outputNameSearchModifierTerms entry is a JSON-encodable
+// value with a "terms" list (each with "name", "fullName", "path" and
+// "code"), a "hasMore" boolean set when i2b2 reported more terms than fit
+// on this page rather than silently truncating, and the "nextOffset" to
+// request the following page.
+func (ds *I2b2DataSource) SearchModifierHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	searchString := parameters["searchString"]
+	if searchString == "" {
+		return nil, fmt.Errorf("i2b2datasource: SearchModifier requires a searchString")
+	}
+	conceptPath := parameters["conceptPath"]
+	if conceptPath == "" {
+		return nil, fmt.Errorf("i2b2datasource: SearchModifier requires a conceptPath")
+	}
+
+	limit := ds.ontMaxElements
+	if rawLimit, ok := parameters["limit"]; ok && rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("i2b2datasource: invalid limit %q", rawLimit)
+		}
+		if parsed < limit {
+			limit = parsed
+		}
+	}
+
+	offset := 0
+	if rawOffset, ok := parameters["offset"]; ok && rawOffset != "" {
+		parsed, err := strconv.Atoi(rawOffset)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("i2b2datasource: invalid offset %q", rawOffset)
+		}
+		offset = parsed
+	}
+
+	result, err := ds.i2b2Client.SearchModifier(ctx, searchString, conceptPath, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: SearchModifier: %w", err)
+	}
+
+	terms := make([]map[string]interface{}, len(result.Terms))
+	for i, term := range result.Terms {
+		terms[i] = map[string]interface{}{
+			"name":     term.Name,
+			"fullName": term.FullName,
+			"path":     term.Path,
+			"code":     term.Code,
+		}
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameSearchModifierTerms: map[string]interface{}{
+			"terms":      terms,
+			"hasMore":    result.HasMore,
+			"nextOffset": result.NextOffset,
+		},
+	}, nil
+}
+
+// SearchModifierByNameHandler performs a free-text search for modifiers by
+// name across the entire ontology tree, as opposed to SearchModifierHandler,
+// which is scoped to modifiers applicable to a single concept.
+//
+// Parameters:
+//   - searchString (string, required): the free-text term to search for.
+//   - limit (string, optional): caps the number of returned terms per page;
+//     defaults to, and is itself capped by, ds.ontMaxElements.
+//   - offset (string, optional): number of matching terms to skip, for
+//     fetching subsequent pages; defaults to 0.
+//
+// The result's outputNameSearchModifierByNameTerms entry is a
+// JSON-encodable value with a "terms" list (each with "name", "fullName",
+// "path", "code" and "appliedPath"), a "hasMore" boolean set when i2b2
+// reported more terms than fit on this page rather than silently
+// truncating, and the "nextOffset" to request the following page.
+func (ds *I2b2DataSource) SearchModifierByNameHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	searchString := parameters["searchString"]
+	if searchString == "" {
+		return nil, fmt.Errorf("i2b2datasource: SearchModifierByName requires a searchString")
+	}
+
+	limit := ds.ontMaxElements
+	if rawLimit, ok := parameters["limit"]; ok && rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("i2b2datasource: invalid limit %q", rawLimit)
+		}
+		if parsed < limit {
+			limit = parsed
+		}
+	}
+
+	offset := 0
+	if rawOffset, ok := parameters["offset"]; ok && rawOffset != "" {
+		parsed, err := strconv.Atoi(rawOffset)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("i2b2datasource: invalid offset %q", rawOffset)
+		}
+		offset = parsed
+	}
+
+	result, err := ds.i2b2Client.SearchModifierByName(ctx, searchString, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: SearchModifierByName: %w", err)
+	}
+
+	terms := make([]map[string]interface{}, len(result.Terms))
+	for i, term := range result.Terms {
+		terms[i] = map[string]interface{}{
+			"name":        term.Name,
+			"fullName":    term.FullName,
+			"path":        term.Path,
+			"code":        term.Code,
+			"appliedPath": term.AppliedPath,
+		}
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameSearchModifierByNameTerms: map[string]interface{}{
+			"terms":      terms,
+			"hasMore":    result.HasMore,
+			"nextOffset": result.NextOffset,
+		},
+	}, nil
+}
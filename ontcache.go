@@ -0,0 +1,106 @@
+package i2b2datasource
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ldsec/geco-i2b2-data-source/i2b2client"
+)
+
+// defaultOntCacheSize bounds how many distinct ontology searches ontCache
+// retains before evicting the least recently used entry.
+const defaultOntCacheSize = 500
+
+// ontCacheEntry is a single cached SearchOntology result.
+type ontCacheEntry struct {
+	key       string
+	result    *i2b2client.SearchOntologyResult
+	expiresAt time.Time
+}
+
+// ontCache is a concurrency-safe, size-bounded LRU cache of SearchOntology
+// results, keyed by the search's parameters. Ontology structure rarely
+// changes, so short-lived repeated searches (e.g. while a user browses a
+// concept tree) can be served without round-tripping to the ONT cell.
+type ontCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	size  int
+	elems map[string]*list.Element
+	order *list.List // front = most recently used
+}
+
+// newOntCache builds an ontCache with the given TTL, applying
+// defaultOntCacheSize when size is non-positive.
+func newOntCache(ttl time.Duration, size int) *ontCache {
+	if size <= 0 {
+		size = defaultOntCacheSize
+	}
+	return &ontCache{
+		ttl:   ttl,
+		size:  size,
+		elems: map[string]*list.Element{},
+		order: list.New(),
+	}
+}
+
+// ontCacheKey builds the cache key for a SearchOntology call from its
+// parameters.
+func ontCacheKey(searchString, category, lang string, offset, limit int) string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%d\x00%d", searchString, category, lang, offset, limit)
+}
+
+func (c *ontCache) get(key string) (*i2b2client.SearchOntologyResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.elems[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*ontCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.elems, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.result, true
+}
+
+func (c *ontCache) put(key string, result *i2b2client.SearchOntologyResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.elems[key]; ok {
+		entry := elem.Value.(*ontCacheEntry)
+		entry.result = result
+		entry.expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&ontCacheEntry{key: key, result: result, expiresAt: time.Now().Add(c.ttl)})
+	c.elems[key] = elem
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.elems, oldest.Value.(*ontCacheEntry).key)
+	}
+}
+
+// parseOntCacheTTL reads the i2b2.api.ont-cache-ttl config key, returning 0
+// (caching disabled) when it is unset.
+func parseOntCacheTTL(config map[string]string) (time.Duration, error) {
+	raw := config["i2b2.api.ont-cache-ttl"]
+	if raw == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("i2b2datasource: parsing i2b2.api.ont-cache-ttl: %w", err)
+	}
+	return ttl, nil
+}
@@ -0,0 +1,394 @@
+package i2b2datasource
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ldsec/geco-i2b2-data-source/i2b2client"
+)
+
+// defaultBatchSearchConceptConcurrency bounds how many concurrent ONT cell
+// get_term_info requests BatchSearchConceptHandler issues at once.
+const defaultBatchSearchConceptConcurrency = 5
+
+// SearchOntologyHandler performs a free-text search across i2b2 ONT cell
+// concept names and returns matching term paths with their metadata.
+//
+// Parameters:
+//   - searchString (string, required): the free-text term to search for.
+//   - category (string, optional): restricts the search to a single
+//     ontology category (e.g. "diagnosis").
+//   - limit (string, optional): caps the number of returned terms per page;
+//     defaults to, and is itself capped by, ds.ontMaxElements.
+//   - offset (string, optional): number of matching terms to skip, for
+//     fetching subsequent pages; defaults to 0.
+//   - noCache (string, optional): when "true", bypasses the ontology cache
+//     (see i2b2.api.ont-cache-ttl) and always queries the ONT cell.
+//   - includeSynonyms (string, optional): when "true", includes synonym
+//     terms in the result; excluded by default to avoid duplicate-looking
+//     entries in a browsable tree.
+//   - includeHidden (string, optional): when "true", includes terms
+//     marked hidden by the hive; excluded by default.
+//   - language (string, optional): requests concept names localized to
+//     this language; a term without a translation falls back to the
+//     hive's default language, reported per-term via "isFallback".
+//     Unset uses the hive's default language.
+//
+// The result's outputNameSearchOntologyTerms entry is a JSON-encodable
+// value with a "terms" list (each with "name", "fullName", "path", "code",
+// "isLeaf", "isSynonym", "isHidden", "isEditable", "language" and
+// "isFallback"), a "hasMore" boolean set when i2b2 reported more terms
+// than fit on this page rather than silently truncating, and the
+// "nextOffset" to request the following page. Filtering out
+// synonyms/hidden terms happens after paging, so a page can come back
+// with fewer than the requested limit even when more terms remain.
+func (ds *I2b2DataSource) SearchOntologyHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	searchString := parameters["searchString"]
+	if searchString == "" {
+		return nil, fmt.Errorf("i2b2datasource: SearchOntology requires a searchString")
+	}
+	category := parameters["category"]
+	language := parameters["language"]
+
+	limit := ds.ontMaxElements
+	if rawLimit, ok := parameters["limit"]; ok && rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("i2b2datasource: invalid limit %q", rawLimit)
+		}
+		if parsed < limit {
+			limit = parsed
+		}
+	}
+
+	offset := 0
+	if rawOffset, ok := parameters["offset"]; ok && rawOffset != "" {
+		parsed, err := strconv.Atoi(rawOffset)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("i2b2datasource: invalid offset %q", rawOffset)
+		}
+		offset = parsed
+	}
+
+	includeSynonyms := parameters["includeSynonyms"] == "true"
+	includeHidden := parameters["includeHidden"] == "true"
+
+	bypassCache := parameters["noCache"] == "true"
+	cacheKey := ontCacheKey(searchString, category, language, offset, limit)
+	if ds.ontCache != nil && !bypassCache {
+		if cached, ok := ds.ontCache.get(cacheKey); ok {
+			loggerFromContext(ctx).Debugf("SearchOntology: cache hit for %q (category %q)", searchString, category)
+			return searchOntologyResultOutput(cached, includeSynonyms, includeHidden, language), nil
+		}
+		loggerFromContext(ctx).Debugf("SearchOntology: cache miss for %q (category %q)", searchString, category)
+	}
+
+	result, err := ds.i2b2Client.SearchOntology(ctx, searchString, category, language, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: SearchOntology: %w", err)
+	}
+	if ds.ontCache != nil && !bypassCache {
+		ds.ontCache.put(cacheKey, result)
+	}
+
+	return searchOntologyResultOutput(result, includeSynonyms, includeHidden, language), nil
+}
+
+// ResolveConceptsHandler resolves a list of concept_cd values (as seen in
+// explore query results) to their fully-qualified ontology path and
+// display name, for downstream display. All distinct codes are batched
+// into a single ONT cell request; a duplicate code in the input reuses
+// the result already fetched for it rather than triggering another
+// lookup.
+//
+// Parameters:
+//   - codes (string, required): comma-separated concept_cd values to
+//     resolve.
+//
+// The result's outputNameResolveConcepts entry is a list, one entry per
+// input code in order, each with "code", a "found" boolean, and, when
+// found, "name", "fullName" and "path".
+func (ds *I2b2DataSource) ResolveConceptsHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	raw := parameters["codes"]
+	if raw == "" {
+		return nil, fmt.Errorf("i2b2datasource: ResolveConcepts requires codes")
+	}
+	codes := strings.Split(raw, ",")
+
+	seen := make(map[string]bool, len(codes))
+	unique := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if !seen[code] {
+			seen[code] = true
+			unique = append(unique, code)
+		}
+	}
+
+	terms, err := ds.i2b2Client.GetConceptsByCode(ctx, unique)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: ResolveConcepts: %w", err)
+	}
+	byCode := make(map[string]i2b2client.OntTerm, len(terms))
+	for _, term := range terms {
+		byCode[term.Code] = term
+	}
+
+	concepts := make([]map[string]interface{}, len(codes))
+	for i, code := range codes {
+		term, ok := byCode[code]
+		if !ok {
+			concepts[i] = map[string]interface{}{"code": code, "found": false}
+			continue
+		}
+		concepts[i] = map[string]interface{}{
+			"code":     code,
+			"found":    true,
+			"name":     term.Name,
+			"fullName": term.FullName,
+			"path":     term.Path,
+		}
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameResolveConcepts: concepts,
+	}, nil
+}
+
+// GetConceptMetadataHandler returns a single concept's metadata (value
+// type, visual attributes, tooltip, units) rather than its children, for
+// a query-building UI that needs to know how to render and validate a
+// value for the concept, and whether it's a leaf term or a container.
+//
+// Parameters:
+//   - conceptPath (string, required): the concept to fetch metadata for.
+//   - language (string, optional): requests the concept's name localized
+//     to this language; falls back to the hive's default language when no
+//     translation exists, reported via "isFallback". Unset uses the
+//     hive's default language.
+//
+// Returns an error satisfying errors.Is(err, i2b2client.ErrConceptNotFound)
+// when conceptPath doesn't exist in the hive.
+func (ds *I2b2DataSource) GetConceptMetadataHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	conceptPath := parameters["conceptPath"]
+	if conceptPath == "" {
+		return nil, fmt.Errorf("i2b2datasource: GetConceptMetadata requires a conceptPath")
+	}
+	language := parameters["language"]
+
+	metadata, err := ds.i2b2Client.GetConceptMetadata(ctx, conceptPath, language)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: GetConceptMetadata: %w", err)
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameGetConceptMetadata: map[string]interface{}{
+			"path":             metadata.Path,
+			"name":             metadata.Name,
+			"valueTypeCode":    metadata.ValueTypeCode,
+			"visualAttributes": metadata.VisualAttributes,
+			"isLeaf":           metadata.IsLeaf(),
+			"tooltip":          metadata.Tooltip,
+			"units":            metadata.Units,
+			"language":         metadata.Lang,
+			"isFallback":       isFallbackLang(language, metadata.Lang),
+		},
+	}, nil
+}
+
+// BatchSearchConceptHandler fetches the children of several concept paths
+// in one call, issuing the underlying ONT get_term_info requests
+// concurrently (bounded by defaultBatchSearchConceptConcurrency) instead of
+// the caller firing one request per node when expanding several tree nodes
+// at once. A failure fetching one path is reported against that path only
+// and does not fail the batch or affect the other paths.
+//
+// Parameters:
+//   - conceptPaths (string, required): comma-separated concept paths to
+//     fetch children for.
+//   - includeSynonyms (string, optional): when "true", includes synonym
+//     children; excluded by default to avoid duplicate-looking nodes in a
+//     browsable tree.
+//   - includeHidden (string, optional): when "true", includes children
+//     marked hidden by the hive; excluded by default.
+//   - language (string, optional): requests the children's names
+//     localized to this language; see GetConceptMetadataHandler for the
+//     fallback behavior. Unset uses the hive's default language.
+//
+// The result's outputNameBatchSearchConceptChildren entry is a map keyed by
+// input concept path, each value having a "children" list (as
+// searchOntologyResultOutput's "terms") on success, or an "error" string on
+// failure for that path.
+func (ds *I2b2DataSource) BatchSearchConceptHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	raw := parameters["conceptPaths"]
+	if raw == "" {
+		return nil, fmt.Errorf("i2b2datasource: BatchSearchConcept requires conceptPaths")
+	}
+	paths := strings.Split(raw, ",")
+	includeSynonyms := parameters["includeSynonyms"] == "true"
+	includeHidden := parameters["includeHidden"] == "true"
+	language := parameters["language"]
+
+	type batchResult struct {
+		path     string
+		children []i2b2client.OntTerm
+		err      error
+	}
+	results := make([]batchResult, len(paths))
+	sem := make(chan struct{}, defaultBatchSearchConceptConcurrency)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			children, err := ds.i2b2Client.GetChildren(ctx, path, language)
+			results[i] = batchResult{path: path, children: children, err: err}
+		}(i, path)
+	}
+	wg.Wait()
+
+	byPath := make(map[string]interface{}, len(results))
+	for _, r := range results {
+		if r.err != nil {
+			byPath[r.path] = map[string]interface{}{"error": r.err.Error()}
+			continue
+		}
+		children := make([]map[string]interface{}, 0, len(r.children))
+		for _, term := range r.children {
+			if term.VisualAttributes.IsSynonym() && !includeSynonyms {
+				continue
+			}
+			if term.VisualAttributes.IsHidden() && !includeHidden {
+				continue
+			}
+			children = append(children, ontTermOutput(term, language))
+		}
+		byPath[r.path] = map[string]interface{}{"children": children}
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameBatchSearchConceptChildren: byPath,
+	}, nil
+}
+
+// SearchConceptByCodeHandler looks up the concept(s) matching a single raw
+// code (e.g. an ICD-10 code a clinician pasted in), as opposed to
+// SearchOntologyHandler's free-text name/path search. It reuses the ONT
+// cell's get_basecode_info lookup (see i2b2client.GetConceptsByCode), which
+// matches across every coding scheme the hive knows about rather than a
+// single one, so a code shared by more than one scheme can come back as
+// more than one concept.
+//
+// Parameters:
+//   - code (string, required): the raw code to look up.
+//
+// The result's outputNameSearchConceptByCode entry is a list (as
+// searchOntologyResultOutput's "terms"), empty rather than an error when
+// the hive doesn't recognize the code.
+func (ds *I2b2DataSource) SearchConceptByCodeHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	code := parameters["code"]
+	if code == "" {
+		return nil, fmt.Errorf("i2b2datasource: SearchConceptByCode requires a code")
+	}
+
+	terms, err := ds.i2b2Client.GetConceptsByCode(ctx, []string{code})
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: SearchConceptByCode: %w", err)
+	}
+
+	result := make([]map[string]interface{}, len(terms))
+	for i, term := range terms {
+		result[i] = ontTermOutput(term, "")
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameSearchConceptByCode: result,
+	}, nil
+}
+
+// GetSchemesHandler lists the ONT cell's configured coding schemes (e.g.
+// ICD-10, LOINC), letting a UI map a concept's code (see OntTerm.Code) back
+// to its source vocabulary.
+//
+// Parameters: none.
+//
+// The result's outputNameGetSchemes entry is a list, one entry per scheme,
+// each with "key", "name" and "description".
+func (ds *I2b2DataSource) GetSchemesHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	schemes, err := ds.i2b2Client.GetSchemes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: GetSchemes: %w", err)
+	}
+
+	result := make([]map[string]interface{}, len(schemes))
+	for i, scheme := range schemes {
+		result[i] = map[string]interface{}{
+			"key":         scheme.Key,
+			"name":        scheme.Name,
+			"description": scheme.Description,
+		}
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameGetSchemes: result,
+	}, nil
+}
+
+// searchOntologyResultOutput converts a SearchOntology result into the
+// handler's output map, shared between the live and cached-hit paths,
+// dropping synonym and/or hidden terms unless includeSynonyms/includeHidden
+// ask to keep them. language is the language that was requested, used to
+// flag terms the hive couldn't translate.
+func searchOntologyResultOutput(result *i2b2client.SearchOntologyResult, includeSynonyms, includeHidden bool, language string) map[OutputDataObjectName]interface{} {
+	terms := make([]map[string]interface{}, 0, len(result.Terms))
+	for _, term := range result.Terms {
+		if term.VisualAttributes.IsSynonym() && !includeSynonyms {
+			continue
+		}
+		if term.VisualAttributes.IsHidden() && !includeHidden {
+			continue
+		}
+		terms = append(terms, ontTermOutput(term, language))
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameSearchOntologyTerms: map[string]interface{}{
+			"terms":      terms,
+			"hasMore":    result.HasMore,
+			"nextOffset": result.NextOffset,
+		},
+	}
+}
+
+// ontTermOutput converts a single OntTerm into its output map, including
+// the booleans derived from its visual attributes. requestedLang is the
+// language that was requested of the ONT cell call that produced term,
+// used to flag a fallback to the hive's default language.
+func ontTermOutput(term i2b2client.OntTerm, requestedLang string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":       term.Name,
+		"fullName":   term.FullName,
+		"path":       term.Path,
+		"code":       term.Code,
+		"isLeaf":     term.VisualAttributes.IsLeaf(),
+		"isSynonym":  term.VisualAttributes.IsSynonym(),
+		"isHidden":   term.VisualAttributes.IsHidden(),
+		"isEditable": term.VisualAttributes.IsEditable(),
+		"language":   term.Lang,
+		"isFallback": isFallbackLang(requestedLang, term.Lang),
+	}
+}
+
+// isFallbackLang reports whether a term returned for requestedLang is
+// actually in a different language, i.e. the hive fell back to its
+// default language for it. A term that doesn't report its language at
+// all (a hive with no localization support) is never flagged as a
+// fallback, since there was no translation to attempt in the first
+// place.
+func isFallbackLang(requestedLang, actualLang string) bool {
+	return requestedLang != "" && actualLang != "" && actualLang != requestedLang
+}
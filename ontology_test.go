@@ -0,0 +1,24 @@
+package i2b2datasource
+
+import "testing"
+
+func TestIsFallbackLang(t *testing.T) {
+	tests := []struct {
+		name          string
+		requestedLang string
+		actualLang    string
+		want          bool
+	}{
+		{"no language requested", "", "en", false},
+		{"hive reports no language support", "fr", "", false},
+		{"translation available", "fr", "fr", false},
+		{"translation missing, fell back", "fr", "en", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFallbackLang(tt.requestedLang, tt.actualLang); got != tt.want {
+				t.Errorf("isFallbackLang(%q, %q) = %v, want %v", tt.requestedLang, tt.actualLang, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package i2b2datasource
+
+import "fmt"
+
+// Operation identifies a single action an I2b2DataSource can perform on
+// behalf of a GeCo query. Operation values are used as the dispatch key in
+// I2b2DataSource.Query.
+type Operation string
+
+const (
+	// OperationExploreQuery runs an i2b2 CRC explore (patient-set) query and
+	// returns the requested result types (patient list, count, ...).
+	OperationExploreQuery Operation = "EXPLORE_QUERY"
+
+	// OperationSearchOntology performs a free-text search against the i2b2 ONT
+	// cell and returns matching concept term paths.
+	OperationSearchOntology Operation = "SEARCH_ONTOLOGY"
+
+	// OperationSearchModifier performs a free-text search against the i2b2
+	// ONT cell for modifiers applicable to a concept.
+	OperationSearchModifier Operation = "SEARCH_MODIFIER"
+
+	// OperationBatchSearchConcept fetches the children of several concept
+	// paths in one call, concurrently, reporting partial failures per path.
+	OperationBatchSearchConcept Operation = "BATCH_SEARCH_CONCEPT"
+
+	// OperationResolveConcepts resolves concept_cd values to their
+	// fully-qualified ontology path and display name.
+	OperationResolveConcepts Operation = "RESOLVE_CONCEPTS"
+
+	// OperationGetConceptMetadata returns a single concept's metadata
+	// (value type, visual attributes, tooltip, units) without its
+	// children.
+	OperationGetConceptMetadata Operation = "GET_CONCEPT_METADATA"
+
+	// OperationCountQuery runs a lightweight i2b2 CRC query requesting only
+	// a patient count (PATIENT_COUNT_XML), without creating or registering
+	// a patient set. Cheaper than OperationExploreQuery with
+	// resultTypes=count and doesn't clutter query history with a set
+	// nobody asked to keep.
+	OperationCountQuery Operation = "COUNT_QUERY"
+
+	// OperationAddCohort persists a named cohort (patient set) so it can be
+	// referenced by later queries.
+	OperationAddCohort Operation = "ADD_COHORT"
+
+	// OperationGetCohorts lists the cohorts previously added by a user.
+	OperationGetCohorts Operation = "GET_COHORTS"
+
+	// OperationGetCohortByName looks up a single cohort by name, instead of
+	// listing all of them and filtering client-side.
+	OperationGetCohortByName Operation = "GET_COHORT_BY_NAME"
+
+	// OperationUpdateCohort renames a cohort and/or repoints it at a
+	// different i2b2 patient set.
+	OperationUpdateCohort Operation = "UPDATE_COHORT"
+
+	// OperationDeleteCohorts deletes a list of cohorts by name in a single
+	// database transaction. By default this is a soft delete (see
+	// i2b2.api.hard-delete-cohorts); soft-deleted cohorts are recoverable
+	// with OperationRestoreCohorts until permanently removed with
+	// OperationPurgeCohorts.
+	OperationDeleteCohorts Operation = "DELETE_COHORTS"
+
+	// OperationRestoreCohorts undoes a soft delete, making a cohort visible
+	// to OperationGetCohorts again. No-op (reported as a per-item failure,
+	// like OperationDeleteCohorts) for a name that isn't currently
+	// soft-deleted.
+	OperationRestoreCohorts Operation = "RESTORE_COHORTS"
+
+	// OperationPurgeCohorts permanently removes soft-deleted cohorts,
+	// bypassing the trash OperationDeleteCohorts left them in. Has no
+	// effect on a cohort that isn't currently soft-deleted.
+	OperationPurgeCohorts Operation = "PURGE_COHORTS"
+
+	// OperationPreviewExploreQuery builds the CRC request an equivalent
+	// OperationExploreQuery call would send and returns its serialized XML
+	// (credentials redacted) without submitting it to the hive.
+	OperationPreviewExploreQuery Operation = "PREVIEW_EXPLORE_QUERY"
+
+	// OperationListProjects lists the i2b2 projects the configured hive
+	// credentials can access, via the PM cell's getUserConfiguration.
+	OperationListProjects Operation = "LIST_PROJECTS"
+
+	// OperationListWorkplaceFolders lists the configured user's WORK cell
+	// workplace folders and the items nested inside them (most commonly
+	// saved query references), as a tree. Read-only: saving a new query
+	// reference into a folder is not yet implemented.
+	OperationListWorkplaceFolders Operation = "LIST_WORKPLACE_FOLDERS"
+
+	// OperationListQueryHistory lists the configured user's previously
+	// submitted query masters (definitions), without re-running them.
+	OperationListQueryHistory Operation = "LIST_QUERY_HISTORY"
+
+	// OperationGetQueryResultInstances fetches a query master's instances
+	// (runs) and their result instances (patient sets, counts, ...).
+	OperationGetQueryResultInstances Operation = "GET_QUERY_RESULT_INSTANCES"
+
+	// OperationRenameQueryMaster renames a previously submitted query
+	// master (definition).
+	OperationRenameQueryMaster Operation = "RENAME_QUERY_MASTER"
+
+	// OperationGetQueryStatus fetches the current status of a previously
+	// submitted CRC query instance, without waiting for it to complete.
+	OperationGetQueryStatus Operation = "GET_QUERY_STATUS"
+
+	// OperationCancelQuery requests the CRC cell abort a previously
+	// submitted query instance.
+	OperationCancelQuery Operation = "CANCEL_QUERY"
+
+	// OperationGetObservationFacts fetches raw observation_fact records for
+	// a patient set, optionally restricted to a set of concept codes, via
+	// the CRC cell's PDO interface.
+	OperationGetObservationFacts Operation = "GET_OBSERVATION_FACTS"
+
+	// OperationLoadData names ingesting an external cohort into i2b2 and
+	// Postgres. Query does not dispatch it and fails with a not-implemented
+	// error; the ingestion itself is I2b2DataSource.LoadData.
+	OperationLoadData Operation = "LOAD_DATA"
+
+	// OperationGetData names exporting a stored query result as tabular
+	// data. Query does not dispatch it and fails with a not-implemented
+	// error; the export itself is I2b2DataSource.GetData.
+	OperationGetData Operation = "GET_DATA"
+
+	// OperationSurvivalQuery is reserved for a future survival analysis
+	// (event/censoring time series) over an explore query's cohort,
+	// optionally stratified into subgroups by a concept or breakdown
+	// dimension, each reported as its own output data object alongside its
+	// patient count. Not yet implemented: unlike the count/breakdown result
+	// types RunQuery already supports, i2b2's CRC PSM has no
+	// survival-analysis result_output type to build on, so this needs a
+	// bespoke computation (most likely derived from per-patient event data
+	// the way OperationGetData does) that hasn't been built yet.
+	OperationSurvivalQuery Operation = "SURVIVAL_QUERY"
+
+	// OperationGetSchemes lists the i2b2 ONT cell's configured coding
+	// schemes (e.g. ICD-10, LOINC), so a caller can map a concept's code to
+	// its source vocabulary.
+	OperationGetSchemes Operation = "GET_SCHEMES"
+
+	// OperationSearchConceptByCode looks up the concept(s) matching a raw
+	// code (e.g. an ICD-10 code), across every coding scheme the hive
+	// knows about, as opposed to OperationSearchOntology's free-text
+	// name/path search.
+	OperationSearchConceptByCode Operation = "SEARCH_CONCEPT_BY_CODE"
+
+	// OperationSearchModifierByName performs a free-text search for
+	// modifiers by name across the entire ontology tree, as opposed to
+	// OperationSearchModifier, which is scoped to modifiers applicable to
+	// a single concept.
+	OperationSearchModifierByName Operation = "SEARCH_MODIFIER_BY_NAME"
+
+	// OperationCrossBreakdownQuery is reserved for a future 2-D
+	// cross-tabulation (e.g. age-group by sex) over an explore query's
+	// cohort. Not yet implemented: i2b2's CRC PSM has no joint result
+	// output type to build on (PATIENT_AGE_COUNT_XML and
+	// PATIENT_GENDER_COUNT_XML each report their own server-computed
+	// category labels against the whole cohort independently, not a joint
+	// count per (age group, sex) pair), and composing one client-side by
+	// re-querying per category would require mapping those labels back to
+	// ontology concept paths the query panels could filter on, which the
+	// hive doesn't expose for the demographic breakdown dimensions. Doing
+	// this properly needs either a richer CRC capability or a demographic
+	// concept-path mapping this client doesn't have yet. Whichever design
+	// lands, each cell of the resulting matrix is an independent small
+	// count and must go through ds.suppressCount individually, the same
+	// way formatBreakdowns applies it per 1-D breakdown entry today — a
+	// cross-tab is more, not less, exposed to re-identification by
+	// intersection than its marginal breakdowns, so suppressing only the
+	// row/column totals would not be sufficient.
+	OperationCrossBreakdownQuery Operation = "CROSS_BREAKDOWN_QUERY"
+)
+
+// OutputDataObjectName identifies one of the named outputs an Operation can
+// produce. GeCo supplies a shared ID per name via outputDataObjectsSharedIDs
+// when it wants that output persisted under a caller-chosen key.
+type OutputDataObjectName string
+
+const (
+	outputNameExploreQueryPatientList    OutputDataObjectName = "patientList"
+	outputNameExploreQueryCount          OutputDataObjectName = "count"
+	outputNameExploreQueryDemographics   OutputDataObjectName = "demographics"
+	outputNameExploreQueryBreakdown      OutputDataObjectName = "breakdown"
+	outputNameExploreQueryEncounterSet   OutputDataObjectName = "encounterSet"
+	outputNameSearchOntologyTerms        OutputDataObjectName = "terms"
+	outputNameSearchModifierTerms        OutputDataObjectName = "modifierTerms"
+	outputNameBatchSearchConceptChildren OutputDataObjectName = "batchChildren"
+	outputNameResolveConcepts            OutputDataObjectName = "concepts"
+	outputNameGetConceptMetadata         OutputDataObjectName = "conceptMetadata"
+	outputNameAddCohort                  OutputDataObjectName = "cohort"
+	outputNameGetCohorts                 OutputDataObjectName = "cohorts"
+	outputNameGetCohortByName            OutputDataObjectName = "cohort"
+	outputNameUpdateCohort               OutputDataObjectName = "cohort"
+	outputNameDeleteCohorts              OutputDataObjectName = "deleteResult"
+	outputNameRestoreCohorts             OutputDataObjectName = "restoreResult"
+	outputNamePurgeCohorts               OutputDataObjectName = "purgeResult"
+	outputNamePreviewExploreQuery        OutputDataObjectName = "previewXML"
+	outputNameListProjects               OutputDataObjectName = "projects"
+	outputNameListWorkplaceFolders       OutputDataObjectName = "workplaceFolders"
+	outputNameListQueryHistory           OutputDataObjectName = "queryHistory"
+	outputNameGetQueryResultInstances    OutputDataObjectName = "queryResultInstances"
+	outputNameRenameQueryMaster          OutputDataObjectName = "queryMaster"
+	outputNameGetQueryStatus             OutputDataObjectName = "queryStatus"
+	outputNameCancelQuery                OutputDataObjectName = "cancelResult"
+	outputNameGetObservationFacts        OutputDataObjectName = "observationFacts"
+	outputNameGetSchemes                 OutputDataObjectName = "schemes"
+	outputNameSearchConceptByCode        OutputDataObjectName = "concepts"
+	outputNameSearchModifierByNameTerms  OutputDataObjectName = "modifierTerms"
+)
+
+// OutputDataObjectNamesFor returns the set of OutputDataObjectNames
+// operation can produce, so a caller can pre-allocate shared IDs or
+// validate an outputDataObjectsSharedIDs map before calling Query instead
+// of discovering a missing one deep inside a handler. The result is the
+// full set an operation is capable of producing, not the subset a
+// particular call's parameters will actually populate: OperationExploreQuery
+// always returns all five of its names here even though, say, a call that
+// only requests resultTypes=count never populates
+// outputNameExploreQueryPatientList.
+//
+// An operation that isn't implemented yet (OperationLoadData,
+// OperationGetData, OperationSurvivalQuery, OperationCrossBreakdownQuery)
+// or isn't recognized returns nil: it produces nothing because dispatch
+// fails before any handler runs.
+func OutputDataObjectNamesFor(operation Operation) []OutputDataObjectName {
+	switch operation {
+	case OperationExploreQuery:
+		return []OutputDataObjectName{
+			outputNameExploreQueryPatientList,
+			outputNameExploreQueryCount,
+			outputNameExploreQueryDemographics,
+			outputNameExploreQueryBreakdown,
+			outputNameExploreQueryEncounterSet,
+		}
+	case OperationSearchOntology:
+		return []OutputDataObjectName{outputNameSearchOntologyTerms}
+	case OperationSearchModifier:
+		return []OutputDataObjectName{outputNameSearchModifierTerms}
+	case OperationSearchModifierByName:
+		return []OutputDataObjectName{outputNameSearchModifierByNameTerms}
+	case OperationBatchSearchConcept:
+		return []OutputDataObjectName{outputNameBatchSearchConceptChildren}
+	case OperationResolveConcepts:
+		return []OutputDataObjectName{outputNameResolveConcepts}
+	case OperationGetConceptMetadata:
+		return []OutputDataObjectName{outputNameGetConceptMetadata}
+	case OperationCountQuery:
+		return []OutputDataObjectName{outputNameExploreQueryCount}
+	case OperationAddCohort:
+		return []OutputDataObjectName{outputNameAddCohort}
+	case OperationGetCohorts:
+		return []OutputDataObjectName{outputNameGetCohorts}
+	case OperationGetCohortByName:
+		return []OutputDataObjectName{outputNameGetCohortByName}
+	case OperationUpdateCohort:
+		return []OutputDataObjectName{outputNameUpdateCohort}
+	case OperationDeleteCohorts:
+		return []OutputDataObjectName{outputNameDeleteCohorts}
+	case OperationRestoreCohorts:
+		return []OutputDataObjectName{outputNameRestoreCohorts}
+	case OperationPurgeCohorts:
+		return []OutputDataObjectName{outputNamePurgeCohorts}
+	case OperationPreviewExploreQuery:
+		return []OutputDataObjectName{outputNamePreviewExploreQuery}
+	case OperationListProjects:
+		return []OutputDataObjectName{outputNameListProjects}
+	case OperationListWorkplaceFolders:
+		return []OutputDataObjectName{outputNameListWorkplaceFolders}
+	case OperationListQueryHistory:
+		return []OutputDataObjectName{outputNameListQueryHistory}
+	case OperationGetQueryResultInstances:
+		return []OutputDataObjectName{outputNameGetQueryResultInstances}
+	case OperationRenameQueryMaster:
+		return []OutputDataObjectName{outputNameRenameQueryMaster}
+	case OperationGetQueryStatus:
+		return []OutputDataObjectName{outputNameGetQueryStatus}
+	case OperationCancelQuery:
+		return []OutputDataObjectName{outputNameCancelQuery}
+	case OperationGetObservationFacts:
+		return []OutputDataObjectName{outputNameGetObservationFacts}
+	case OperationGetSchemes:
+		return []OutputDataObjectName{outputNameGetSchemes}
+	case OperationSearchConceptByCode:
+		return []OutputDataObjectName{outputNameSearchConceptByCode}
+	default:
+		return nil
+	}
+}
+
+// validateOutputDataObjectsSharedIDs checks that sharedIDs contains an
+// entry for every OutputDataObjectName operation can produce, so a missing
+// one surfaces here as a clear configuration error instead of a nil-map
+// panic or a silently dropped output deep inside the dispatched handler.
+func validateOutputDataObjectsSharedIDs(operation Operation, sharedIDs map[OutputDataObjectName]string) error {
+	for _, name := range OutputDataObjectNamesFor(operation) {
+		if _, ok := sharedIDs[name]; !ok {
+			return fmt.Errorf("i2b2datasource: outputDataObjectsSharedIDs is missing an entry for %q, required by operation %s", name, operation)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package i2b2datasource
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOutputDataObjectNamesFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation Operation
+		want      []OutputDataObjectName
+	}{
+		{
+			"explore query produces its full output set regardless of parameters",
+			OperationExploreQuery,
+			[]OutputDataObjectName{
+				outputNameExploreQueryPatientList,
+				outputNameExploreQueryCount,
+				outputNameExploreQueryDemographics,
+				outputNameExploreQueryBreakdown,
+				outputNameExploreQueryEncounterSet,
+			},
+		},
+		{"count query shares explore query's count output", OperationCountQuery, []OutputDataObjectName{outputNameExploreQueryCount}},
+		{"single-output operation", OperationGetSchemes, []OutputDataObjectName{outputNameGetSchemes}},
+		{"not yet implemented operation produces nothing", OperationSurvivalQuery, nil},
+		{"not yet implemented operation produces nothing", OperationCrossBreakdownQuery, nil},
+		{"unknown operation produces nothing", Operation("NOT_A_REAL_OPERATION"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OutputDataObjectNamesFor(tt.operation); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OutputDataObjectNamesFor(%q) = %v, want %v", tt.operation, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOutputDataObjectsSharedIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation Operation
+		sharedIDs map[OutputDataObjectName]string
+		wantErr   bool
+	}{
+		{"nil map, operation needs nothing", OperationSurvivalQuery, nil, false},
+		{"nil map, operation needs an entry", OperationGetSchemes, nil, true},
+		{"complete map", OperationGetSchemes, map[OutputDataObjectName]string{outputNameGetSchemes: "schemes-id"}, false},
+		{
+			"missing one of several required entries",
+			OperationExploreQuery,
+			map[OutputDataObjectName]string{
+				outputNameExploreQueryPatientList:  "a",
+				outputNameExploreQueryCount:        "b",
+				outputNameExploreQueryDemographics: "c",
+				outputNameExploreQueryBreakdown:    "d",
+			},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOutputDataObjectsSharedIDs(tt.operation, tt.sharedIDs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateOutputDataObjectsSharedIDs(%q, %v) err = %v, wantErr %v", tt.operation, tt.sharedIDs, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package i2b2datasource
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueryTiming selects how an explore query's panels are sequenced by the
+// CRC cell. QueryTimingImmediate panels have no temporal relationship to
+// each other; QueryTimingSequential panels are tied together by per-panel
+// Events.
+type QueryTiming string
+
+const (
+	QueryTimingImmediate  QueryTiming = "IMMEDIATE"
+	QueryTimingSequential QueryTiming = "ANY"
+)
+
+// Event ties a Panel to another panel in the same query by temporal
+// operator, e.g. "this panel occurs AFTER panel 1".
+type Event struct {
+	PanelNum int
+	Operator string // BEFORE, AFTER, SAME_VISIT, ...
+}
+
+// Item is a single concept reference within a Panel, optionally constrained
+// by an observed value or by a modifier. Exactly one of ConceptPath or
+// PatientSetID must be set.
+type Item struct {
+	ConceptPath string
+	Value       *ValueConstraint
+
+	// ModifierKey and ModifierCD identify a modifier applied to
+	// ConceptPath, e.g. "primary" diagnosis. ModifierValue optionally
+	// constrains the modifier's own observed value. A modifier may only be
+	// attached to an item that also has a ConceptPath.
+	ModifierKey   string
+	ModifierCD    string
+	ModifierValue *ValueConstraint
+
+	// PatientSetID references a previously computed i2b2 patient set by its
+	// result instance id instead of an ontology concept, so a follow-on
+	// query (e.g. an intersection with a newly added panel, or a survival
+	// analysis) can build on a prior result without recomputing it. Mutually
+	// exclusive with ConceptPath; Value/ModifierKey do not apply to it since
+	// a referenced patient set carries no per-item modifier. Callers should
+	// confirm the referenced set is one the requesting user/project may use
+	// before it reaches Item (see ExploreQueryHandler's validation of the
+	// "patientSetID" panel parameter against the cohort table).
+	PatientSetID string
+}
+
+// Validate rejects an item that carries a modifier reference but no
+// concept to attach it to, an item that sets neither ConceptPath nor
+// PatientSetID, and an item that sets both or attaches a value/modifier
+// constraint to a PatientSetID reference.
+func (it Item) Validate() error {
+	if it.ConceptPath == "" && it.PatientSetID == "" {
+		return fmt.Errorf("i2b2datasource: item requires a concept path or a patient set ID")
+	}
+	if it.ConceptPath != "" && it.PatientSetID != "" {
+		return fmt.Errorf("i2b2datasource: item must not set both a concept path and a patient set ID")
+	}
+	if it.PatientSetID != "" {
+		if it.Value != nil || it.ModifierKey != "" {
+			return fmt.Errorf("i2b2datasource: item referencing patient set %q must not set a value or modifier constraint", it.PatientSetID)
+		}
+		return nil
+	}
+	if it.ModifierKey != "" && it.ConceptPath == "" {
+		return fmt.Errorf("i2b2datasource: item modifier_key %q requires a concept path", it.ModifierKey)
+	}
+	if it.ModifierValue != nil {
+		if it.ModifierKey == "" {
+			return fmt.Errorf("i2b2datasource: item modifier value constraint requires a modifier_key")
+		}
+		if err := it.ModifierValue.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Panel is a group of concept Items in an explore query, matched by i2b2's
+// default "any item in the panel" (OR) semantics: a patient matches the
+// panel if they have at least Occurrences observations of any one of
+// Items, or (Invert) none. QueryDefinition ANDs Panels together, so e.g. a
+// 2-panel query with 3 items in panel 1 and 1 item in panel 2 matches
+// patients with (item1a OR item1b OR item1c) AND item2. A panel may
+// additionally be tied to another panel via a temporal Event.
+type Panel struct {
+	Num   int
+	Items []Item
+	Event *Event // nil for panels with no temporal constraint
+
+	DateRange *DateConstraint // nil for panels with no date constraint
+
+	// Occurrences is the minimum number of times a concept must occur for
+	// the panel to match. Zero is treated as the i2b2 default of 1; the
+	// <total_item_occurrences> element is only emitted when Occurrences is
+	// greater than 1.
+	Occurrences int
+
+	// Invert excludes patients matching the panel instead of requiring
+	// them, e.g. "has concept A but NOT concept B".
+	Invert bool
+}
+
+// Validate rejects a panel with no items: i2b2 has no meaningful way to
+// match "any of zero items", so an empty panel (e.g. from a caller-side
+// item list that got filtered down to nothing) is almost always a bug
+// rather than an intentional "match everyone" panel.
+func (p Panel) Validate() error {
+	if len(p.Items) == 0 {
+		return fmt.Errorf("i2b2datasource: panel %d requires at least one item", p.Num)
+	}
+	return nil
+}
+
+// QueryDefinition is a full explore query: its timing mode and ordered
+// panels. Panels are ANDed together; see Panel for how a single panel's
+// items combine.
+type QueryDefinition struct {
+	Timing QueryTiming
+	Panels []Panel
+}
+
+// BuildQueryXML renders q as the CRC <query_definition> message body
+// expected by a setfinder request, including the query_timing element and
+// each panel's panel/event elements.
+func (q QueryDefinition) BuildQueryXML() string {
+	var panels strings.Builder
+	for _, p := range q.Panels {
+		panels.WriteString(p.buildXML())
+	}
+	return fmt.Sprintf(`<query_definition>
+      <query_timing>%s</query_timing>
+      %s
+    </query_definition>`, q.Timing, panels.String())
+}
+
+func (p Panel) buildXML() string {
+	var items strings.Builder
+	for _, it := range p.Items {
+		items.WriteString(it.buildXML())
+	}
+	eventXML := ""
+	if p.Event != nil {
+		eventXML = fmt.Sprintf(`<event><panel_number>%d</panel_number><operator>%s</operator></event>`, p.Event.PanelNum, escapeXML(p.Event.Operator))
+	}
+	dateRangeXML := ""
+	if p.DateRange != nil {
+		dateRangeXML = p.DateRange.buildXML()
+	}
+	occurrencesXML := ""
+	if p.Occurrences > 1 {
+		occurrencesXML = fmt.Sprintf("<total_item_occurrences>%d</total_item_occurrences>", p.Occurrences)
+	}
+	invertXML := ""
+	if p.Invert {
+		invertXML = "<invert>1</invert>"
+	}
+	return fmt.Sprintf(`<panel>
+      <panel_number>%d</panel_number>
+      %s
+      %s
+      %s
+      %s
+      %s
+    </panel>`, p.Num, eventXML, dateRangeXML, occurrencesXML, invertXML, items.String())
+}
+
+func (it Item) buildXML() string {
+	if it.PatientSetID != "" {
+		return fmt.Sprintf(`<item><item_key>SET:%s</item_key></item>`, escapeXML(it.PatientSetID))
+	}
+	valueXML := ""
+	if it.Value != nil {
+		valueXML = it.Value.buildXML()
+	}
+	modifierXML := ""
+	if it.ModifierKey != "" {
+		modifierValueXML := ""
+		if it.ModifierValue != nil {
+			modifierValueXML = it.ModifierValue.buildXML()
+		}
+		modifierXML = fmt.Sprintf(`<constrain_by_modifier>
+          <modifier_key>%s</modifier_key>
+          <modifier_cd>%s</modifier_cd>
+          %s
+        </constrain_by_modifier>`, escapeXML(it.ModifierKey), escapeXML(it.ModifierCD), modifierValueXML)
+	}
+	return fmt.Sprintf(`<item><item_key>%s</item_key>%s%s</item>`, escapeXML(it.ConceptPath), valueXML, modifierXML)
+}
+
+// exampleTwoEventTemporalQuery demonstrates using QueryDefinition's
+// temporal support to express "eventB occurs after eventA", e.g. a
+// diagnosis followed by a prescription.
+func exampleTwoEventTemporalQuery(eventAConceptPath, eventBConceptPath string) QueryDefinition {
+	return QueryDefinition{
+		Timing: QueryTimingSequential,
+		Panels: []Panel{
+			{Num: 1, Items: []Item{{ConceptPath: eventAConceptPath}}},
+			{Num: 2, Items: []Item{{ConceptPath: eventBConceptPath}}, Event: &Event{PanelNum: 1, Operator: "AFTER"}},
+		},
+	}
+}
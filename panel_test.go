@@ -0,0 +1,185 @@
+package i2b2datasource
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPanelBuildXMLTotalItemOccurrences checks that a panel requiring at
+// least 3 occurrences of its concept serializes a <total_item_occurrences>
+// element matching the i2b2 reference sample.
+func TestPanelBuildXMLTotalItemOccurrences(t *testing.T) {
+	p := Panel{
+		Num:         1,
+		Items:       []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\X"}},
+		Occurrences: 3,
+	}
+
+	xml := p.buildXML()
+
+	want := "<total_item_occurrences>3</total_item_occurrences>"
+	if !strings.Contains(xml, want) {
+		t.Fatalf("buildXML() = %s, want it to contain %s", xml, want)
+	}
+}
+
+// TestPanelBuildXMLOmitsOccurrencesWhenDefault checks that the element is
+// omitted when Occurrences is left at its zero value (interpreted as 1).
+func TestPanelBuildXMLOmitsOccurrencesWhenDefault(t *testing.T) {
+	p := Panel{Num: 1, Items: []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\X"}}}
+
+	xml := p.buildXML()
+
+	if strings.Contains(xml, "total_item_occurrences") {
+		t.Fatalf("buildXML() = %s, want no total_item_occurrences element", xml)
+	}
+}
+
+// TestItemBuildXMLPatientSetReference checks that an item referencing an
+// existing patient set serializes its item_key from PatientSetID instead of
+// ConceptPath.
+func TestItemBuildXMLPatientSetReference(t *testing.T) {
+	it := Item{PatientSetID: "12345"}
+
+	xml := it.buildXML()
+
+	want := "<item><item_key>SET:12345</item_key></item>"
+	if xml != want {
+		t.Fatalf("buildXML() = %s, want %s", xml, want)
+	}
+}
+
+// TestItemValidateRejectsConceptPathAndPatientSetID checks that an item
+// cannot reference both an ontology concept and a prior patient set.
+func TestItemValidateRejectsConceptPathAndPatientSetID(t *testing.T) {
+	it := Item{ConceptPath: "\\\\i2b2\\Diagnoses\\A", PatientSetID: "12345"}
+
+	if err := it.Validate(); err == nil {
+		t.Fatalf("Validate() = nil, want an error")
+	}
+}
+
+// TestItemValidateRejectsValueConstraintOnPatientSetReference checks that a
+// patient set reference item cannot also carry a value constraint, which
+// only applies to a concept observation.
+func TestItemValidateRejectsValueConstraintOnPatientSetReference(t *testing.T) {
+	it := Item{PatientSetID: "12345", Value: &ValueConstraint{Operator: ValueOperatorEQ, Type: ValueTypeText, Value: "x"}}
+
+	if err := it.Validate(); err == nil {
+		t.Fatalf("Validate() = nil, want an error")
+	}
+}
+
+// TestQueryDefinitionBuildXMLInvertedPanel checks a query with one positive
+// panel (concept A) and one inverted panel (NOT concept B) serializes an
+// <invert>1</invert> element only on the inverted panel.
+func TestQueryDefinitionBuildXMLInvertedPanel(t *testing.T) {
+	q := QueryDefinition{
+		Timing: QueryTimingImmediate,
+		Panels: []Panel{
+			{Num: 1, Items: []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\A"}}},
+			{Num: 2, Items: []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\B"}}, Invert: true},
+		},
+	}
+
+	xml := q.BuildQueryXML()
+
+	if strings.Count(xml, "<invert>1</invert>") != 1 {
+		t.Fatalf("BuildQueryXML() = %s, want exactly one <invert>1</invert> element", xml)
+	}
+}
+
+// TestQueryDefinitionBuildXMLMultiplePanelsMultipleItems checks a 3-panel
+// query where panel 1 has 3 items and panels 2-3 have 1 item each
+// serializes 3 <panel> elements (within-panel OR, across-panel AND is
+// i2b2's default CRC interpretation of this shape: a patient matches if
+// they have conceptA1 OR conceptA2 OR conceptA3, AND conceptB, AND
+// conceptC) with each panel's own items nested inside it and no items
+// bleeding across panel boundaries.
+func TestQueryDefinitionBuildXMLMultiplePanelsMultipleItems(t *testing.T) {
+	q := QueryDefinition{
+		Timing: QueryTimingImmediate,
+		Panels: []Panel{
+			{Num: 1, Items: []Item{
+				{ConceptPath: "\\\\i2b2\\Diagnoses\\A1"},
+				{ConceptPath: "\\\\i2b2\\Diagnoses\\A2"},
+				{ConceptPath: "\\\\i2b2\\Diagnoses\\A3"},
+			}},
+			{Num: 2, Items: []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\B"}}},
+			{Num: 3, Items: []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\C"}}},
+		},
+	}
+
+	xml := q.BuildQueryXML()
+
+	if got := strings.Count(xml, "<panel>"); got != 3 {
+		t.Fatalf("BuildQueryXML() has %d <panel> elements, want 3: %s", got, xml)
+	}
+	if got := strings.Count(xml, "<item>"); got != 5 {
+		t.Fatalf("BuildQueryXML() has %d <item> elements, want 5: %s", got, xml)
+	}
+
+	panel1 := xml[strings.Index(xml, "<panel_number>1</panel_number>"):strings.Index(xml, "<panel_number>2</panel_number>")]
+	for _, concept := range []string{"A1", "A2", "A3"} {
+		if !strings.Contains(panel1, concept) {
+			t.Fatalf("panel 1 = %s, want it to contain concept %s", panel1, concept)
+		}
+	}
+	if strings.Contains(panel1, "\\B") || strings.Contains(panel1, "\\C") {
+		t.Fatalf("panel 1 = %s, want it not to contain panel 2/3's items", panel1)
+	}
+}
+
+// TestQueryDefinitionBuildXMLMultiplePanelsMultipleItemsEscapesConceptPath
+// checks that the same 3-panel, multi-item assembly escapes a concept path
+// containing XML metacharacters in one item, rather than letting it break
+// out of its own <item_key> and merge into a neighboring panel.
+func TestQueryDefinitionBuildXMLMultiplePanelsMultipleItemsEscapesConceptPath(t *testing.T) {
+	q := QueryDefinition{
+		Timing: QueryTimingImmediate,
+		Panels: []Panel{
+			{Num: 1, Items: []Item{
+				{ConceptPath: "\\\\i2b2\\Diagnoses\\A1</item><item><item_key>\\\\i2b2\\Diagnoses\\Injected"},
+				{ConceptPath: "\\\\i2b2\\Diagnoses\\A2"},
+			}},
+			{Num: 2, Items: []Item{{ConceptPath: "\\\\i2b2\\Diagnoses\\B"}}},
+		},
+	}
+
+	xml := q.BuildQueryXML()
+
+	if got := strings.Count(xml, "<item>"); got != 3 {
+		t.Fatalf("BuildQueryXML() has %d <item> elements, want 3 (the injected markup must not add a 4th): %s", got, xml)
+	}
+	if strings.Contains(xml, "<item_key>\\\\i2b2\\Diagnoses\\Injected</item_key>") {
+		t.Fatalf("BuildQueryXML() = %s, want the injected item_key escaped away, not serialized as its own item", xml)
+	}
+}
+
+// TestItemBuildXMLEscapesConceptPath checks that a concept path containing
+// XML metacharacters is escaped rather than spliced verbatim into the
+// item, where it could otherwise inject a sibling element/panel into the
+// query actually sent to the hive.
+func TestItemBuildXMLEscapesConceptPath(t *testing.T) {
+	it := Item{ConceptPath: `\i2b2\Diagnoses\A & B<`}
+
+	xml := it.buildXML()
+
+	if strings.Contains(xml, "A & B<") {
+		t.Fatalf("buildXML() = %s, want the concept path escaped", xml)
+	}
+	want := "A &amp; B&lt;"
+	if !strings.Contains(xml, want) {
+		t.Fatalf("buildXML() = %s, want it to contain %s", xml, want)
+	}
+}
+
+// TestPanelValidateRejectsEmptyPanel checks that a panel with no items is
+// rejected: i2b2 has no meaningful way to match "any of zero items".
+func TestPanelValidateRejectsEmptyPanel(t *testing.T) {
+	p := Panel{Num: 1}
+
+	if err := p.Validate(); err == nil {
+		t.Fatalf("Validate() = nil, want an error")
+	}
+}
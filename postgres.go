@@ -0,0 +1,208 @@
+package i2b2datasource
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// Sane defaults applied when the corresponding db.* config key is unset.
+const (
+	defaultMaxOpenConns    = 10
+	defaultMaxIdleConns    = 5
+	defaultConnMaxLifetime = 30 * time.Minute
+)
+
+// PostgresDatabase stores cohorts (named patient sets) for an I2b2DataSource.
+type PostgresDatabase struct {
+	db *sql.DB
+
+	// metrics receives DB latency observations; defaults to noopMetrics.
+	// Set by I2b2DataSource.SetMetrics since it can't be threaded through
+	// NewPostgresDatabase's flat config map.
+	metrics Metrics
+}
+
+// Close releases the underlying connection pool, waiting for connections
+// currently in use to be returned before closing them. Callers should stop
+// issuing new queries against pg before calling Close.
+func (pg *PostgresDatabase) Close() error {
+	return pg.db.Close()
+}
+
+// Ping verifies the database is reachable by running a trivial query against
+// it, honoring ctx cancellation.
+func (pg *PostgresDatabase) Ping(ctx context.Context) error {
+	defer pg.observeLatency(ctx, time.Now())
+	var one int
+	return pg.db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// ExecContext runs query against the underlying connection pool, honoring
+// ctx cancellation, so a caller's deadline bounds the database call the
+// same way it bounds the i2b2 client calls in the same handler.
+func (pg *PostgresDatabase) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer pg.observeLatency(ctx, time.Now())
+	return pg.db.ExecContext(ctx, query, args...)
+}
+
+// QueryContext runs query against the underlying connection pool, honoring
+// ctx cancellation.
+func (pg *PostgresDatabase) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer pg.observeLatency(ctx, time.Now())
+	return pg.db.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs query against the underlying connection pool,
+// honoring ctx cancellation, and returns at most one row. Scanning the
+// returned *sql.Row yields sql.ErrNoRows when query matched nothing.
+func (pg *PostgresDatabase) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer pg.observeLatency(ctx, time.Now())
+	return pg.db.QueryRowContext(ctx, query, args...)
+}
+
+// WithTx runs fn against a single Postgres transaction, honoring ctx
+// cancellation. It commits if fn returns nil and rolls back otherwise, so a
+// caller that needs several statements to apply atomically (e.g. bulk
+// deletion) doesn't end up with some of them applied and others not.
+func (pg *PostgresDatabase) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	defer pg.observeLatency(ctx, time.Now())
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("i2b2datasource: beginning transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// observeLatency reports the elapsed time since start to pg.metrics, and
+// adds it to ctx's operationTimers, if any, so Query can report how much
+// of an operation's total duration went to the database.
+func (pg *PostgresDatabase) observeLatency(ctx context.Context, start time.Time) {
+	duration := time.Since(start)
+	pg.metrics.ObserveDBLatency(duration)
+	operationTimersFromContext(ctx).addDB(duration)
+}
+
+// NewPostgresDatabase opens a connection to the Postgres instance described
+// by the db.* keys in config (host, port, user, password, name) and
+// verifies it is reachable. Pool sizing keys:
+//   - db.max-open-conns: maximum open connections, default 10.
+//   - db.max-idle-conns: maximum idle connections, default 5. Must not
+//     exceed db.max-open-conns.
+//   - db.conn-max-lifetime: maximum connection lifetime, parsed with
+//     time.ParseDuration, default 30m.
+func NewPostgresDatabase(config map[string]string) (*PostgresDatabase, error) {
+	dsn, err := buildPostgresDSN(config)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: opening postgres connection: %w", err)
+	}
+
+	maxOpenConns, err := intConfigOrDefault(config, "db.max-open-conns", defaultMaxOpenConns)
+	if err != nil {
+		return nil, err
+	}
+	maxIdleConns, err := intConfigOrDefault(config, "db.max-idle-conns", defaultMaxIdleConns)
+	if err != nil {
+		return nil, err
+	}
+	if maxIdleConns > maxOpenConns {
+		return nil, fmt.Errorf("i2b2datasource: db.max-idle-conns (%d) must not exceed db.max-open-conns (%d)", maxIdleConns, maxOpenConns)
+	}
+	connMaxLifetime := defaultConnMaxLifetime
+	if raw, ok := config["db.conn-max-lifetime"]; ok && raw != "" {
+		connMaxLifetime, err = time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("i2b2datasource: parsing db.conn-max-lifetime: %w", err)
+		}
+	}
+
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+	db.SetConnMaxLifetime(connMaxLifetime)
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("i2b2datasource: pinging postgres: %w", err)
+	}
+	return &PostgresDatabase{db: db, metrics: noopMetrics{}}, nil
+}
+
+// validPostgresSSLModes are the sslmode values accepted by lib/pq.
+var validPostgresSSLModes = map[string]bool{
+	"disable":     true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// buildPostgresDSN builds the Postgres connection string from config,
+// defaulting db.ssl-mode to "disable" and requiring db.ssl-root-cert when
+// ssl-mode is verify-ca or verify-full (the modes that verify the server
+// certificate against a CA).
+func buildPostgresDSN(config map[string]string) (string, error) {
+	sslMode := config["db.ssl-mode"]
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	if !validPostgresSSLModes[sslMode] {
+		return "", fmt.Errorf("i2b2datasource: invalid db.ssl-mode %q", sslMode)
+	}
+
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		config["db.host"], config["db.port"], config["db.user"], config["db.password"], config["db.name"], sslMode,
+	)
+
+	if sslMode == "verify-ca" || sslMode == "verify-full" {
+		rootCert := config["db.ssl-root-cert"]
+		if rootCert == "" {
+			return "", fmt.Errorf("i2b2datasource: db.ssl-root-cert is required for db.ssl-mode %q", sslMode)
+		}
+		if _, err := os.Stat(rootCert); err != nil {
+			return "", fmt.Errorf("i2b2datasource: db.ssl-root-cert %q: %w", rootCert, err)
+		}
+		dsn += fmt.Sprintf(" sslrootcert=%s", rootCert)
+	}
+
+	if cert, key := config["db.ssl-cert"], config["db.ssl-key"]; cert != "" || key != "" {
+		if cert == "" || key == "" {
+			return "", fmt.Errorf("i2b2datasource: db.ssl-cert and db.ssl-key must both be set")
+		}
+		if _, err := os.Stat(cert); err != nil {
+			return "", fmt.Errorf("i2b2datasource: db.ssl-cert %q: %w", cert, err)
+		}
+		if _, err := os.Stat(key); err != nil {
+			return "", fmt.Errorf("i2b2datasource: db.ssl-key %q: %w", key, err)
+		}
+		dsn += fmt.Sprintf(" sslcert=%s sslkey=%s", cert, key)
+	}
+
+	return dsn, nil
+}
+
+// intConfigOrDefault parses config[key] as an int, returning def when the
+// key is unset or empty.
+func intConfigOrDefault(config map[string]string, key string, def int) (int, error) {
+	raw, ok := config[key]
+	if !ok || raw == "" {
+		return def, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("i2b2datasource: parsing %s: %w", key, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,37 @@
+package i2b2datasource
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListProjectsHandler lists the i2b2 projects the configured hive
+// credentials can access, via the PM cell's getUserConfiguration. Useful
+// for an admin validating i2b2.api.project at setup time, or for a caller
+// discovering which values are valid for ExploreQueryHandler's projectID
+// override (see validateProjectAccess). Rejected credentials surface as an
+// error satisfying errors.Is(err, i2b2client.ErrAuthentication).
+//
+// Parameters: none.
+//
+// The result's outputNameListProjects entry is a list, each entry with
+// "id", "name" and "role".
+func (ds *I2b2DataSource) ListProjectsHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	userConfig, err := ds.i2b2Client.GetUserConfiguration(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: ListProjects: %w", err)
+	}
+
+	projects := make([]map[string]interface{}, len(userConfig.Projects))
+	for i, project := range userConfig.Projects {
+		projects[i] = map[string]interface{}{
+			"id":   project.ID,
+			"name": project.Name,
+			"role": project.Role,
+		}
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameListProjects: projects,
+	}, nil
+}
@@ -0,0 +1,175 @@
+package i2b2datasource
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListQueryHistoryHandler lists the configured user's previously
+// submitted query masters (definitions), via the CRC cell's
+// getQueryMasterList request, so a UI can show query history without
+// re-running anything.
+//
+// Parameters: none.
+//
+// The result's outputNameListQueryHistory entry is a list, each entry with
+// "queryMasterID", "name", "createDate" and "status" (its most recent
+// run's status, or "" if it was never run).
+func (ds *I2b2DataSource) ListQueryHistoryHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	masters, err := ds.i2b2Client.ListQueryMasters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: ListQueryHistory: %w", err)
+	}
+
+	history := make([]map[string]interface{}, len(masters))
+	for i, m := range masters {
+		history[i] = map[string]interface{}{
+			"queryMasterID": m.QueryMasterID,
+			"name":          m.Name,
+			"createDate":    m.CreateDate,
+			"status":        m.Status,
+		}
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameListQueryHistory: history,
+	}, nil
+}
+
+// GetQueryResultInstancesHandler fetches a query master's instances (runs)
+// and, nested inside each, its result instances (patient sets, counts,
+// ...), via the CRC cell's getQueryResultInstanceList request. Used after
+// ListQueryHistoryHandler once the user has picked a query to inspect.
+//
+// Parameters:
+//   - queryMasterID (string, required): the query master id returned by
+//     ListQueryHistoryHandler.
+//
+// The result's outputNameGetQueryResultInstances entry is a list, each
+// entry a query instance with "queryInstanceID", "startDate", "status" and
+// "resultInstances" (itself a list of "resultInstanceID", "resultType",
+// "setSize" and "status").
+func (ds *I2b2DataSource) GetQueryResultInstancesHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	queryMasterID := parameters["queryMasterID"]
+	if queryMasterID == "" {
+		return nil, fmt.Errorf("i2b2datasource: GetQueryResultInstances requires queryMasterID")
+	}
+
+	instances, err := ds.i2b2Client.GetQueryResultInstances(ctx, queryMasterID)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: GetQueryResultInstances: %w", err)
+	}
+
+	result := make([]map[string]interface{}, len(instances))
+	for i, qi := range instances {
+		resultInstances := make([]map[string]interface{}, len(qi.ResultInstances))
+		for j, r := range qi.ResultInstances {
+			resultInstances[j] = map[string]interface{}{
+				"resultInstanceID": r.ResultInstanceID,
+				"resultType":       r.ResultTypeName,
+				"setSize":          r.SetSize,
+				"status":           r.Status,
+			}
+		}
+		result[i] = map[string]interface{}{
+			"queryInstanceID": qi.QueryInstanceID,
+			"startDate":       qi.StartDate,
+			"status":          qi.Status,
+			"resultInstances": resultInstances,
+		}
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameGetQueryResultInstances: result,
+	}, nil
+}
+
+// RenameQueryMasterHandler renames a previously submitted query master
+// (definition), via the CRC cell's renameQueryMaster request. A rejected
+// rename because another master already uses newName surfaces as an error
+// satisfying errors.Is(err, i2b2client.ErrDuplicateName).
+//
+// Parameters:
+//   - queryMasterID (string, required): the query master id to rename,
+//     as returned by ListQueryHistoryHandler.
+//   - newName (string, required): the new, non-empty name.
+//
+// The result's outputNameRenameQueryMaster entry is the updated master,
+// with "queryMasterID" and "name".
+func (ds *I2b2DataSource) RenameQueryMasterHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	queryMasterID := parameters["queryMasterID"]
+	if queryMasterID == "" {
+		return nil, fmt.Errorf("i2b2datasource: RenameQueryMaster requires queryMasterID")
+	}
+	newName := parameters["newName"]
+	if newName == "" {
+		return nil, fmt.Errorf("i2b2datasource: RenameQueryMaster requires newName")
+	}
+
+	master, err := ds.i2b2Client.RenameQueryMaster(ctx, queryMasterID, newName)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: RenameQueryMaster: %w", err)
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameRenameQueryMaster: map[string]interface{}{
+			"queryMasterID": master.QueryMasterID,
+			"name":          master.Name,
+		},
+	}, nil
+}
+
+// GetQueryStatusHandler fetches the current status of a previously
+// submitted CRC query instance, so a UI can poll it for progress
+// independently of whatever originally submitted the query (e.g. after a
+// page reload).
+//
+// Parameters:
+//   - queryInstanceID (string, required): the CRC query instance id
+//     returned as part of ExploreQuery's patient list result.
+func (ds *I2b2DataSource) GetQueryStatusHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	queryInstanceID := parameters["queryInstanceID"]
+	if queryInstanceID == "" {
+		return nil, fmt.Errorf("i2b2datasource: GetQueryStatus requires queryInstanceID")
+	}
+
+	status, err := ds.i2b2Client.GetQueryStatus(ctx, queryInstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: GetQueryStatus: %w", err)
+	}
+
+	conditions := make([]map[string]interface{}, len(status.Conditions))
+	for i, c := range status.Conditions {
+		conditions[i] = map[string]interface{}{"type": c.Type, "text": c.Text}
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameGetQueryStatus: map[string]interface{}{
+			"type":       status.Type,
+			"text":       status.Text,
+			"conditions": conditions,
+		},
+	}, nil
+}
+
+// CancelQueryHandler requests the CRC cell abort a previously submitted
+// query instance, e.g. so a user can stop a query they no longer want to
+// wait for.
+//
+// Parameters:
+//   - queryInstanceID (string, required): the CRC query instance id to
+//     cancel.
+func (ds *I2b2DataSource) CancelQueryHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	queryInstanceID := parameters["queryInstanceID"]
+	if queryInstanceID == "" {
+		return nil, fmt.Errorf("i2b2datasource: CancelQuery requires queryInstanceID")
+	}
+
+	if err := ds.i2b2Client.CancelQuery(ctx, queryInstanceID); err != nil {
+		return nil, fmt.Errorf("i2b2datasource: CancelQuery: %w", err)
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameCancelQuery: map[string]interface{}{"queryInstanceID": queryInstanceID, "cancelled": true},
+	}, nil
+}
@@ -0,0 +1,166 @@
+package i2b2datasource
+
+import "fmt"
+
+// Config is a typed view of the flat configuration map NewI2b2DataSource
+// accepts: one field per recognized key, named after it, so a typo'd key
+// (which the map form silently treats as unset) is caught by the compiler
+// instead of becoming a runtime misconfiguration. See NewI2b2DataSource's
+// doc comment for what each key controls.
+//
+// Every field holds the same string representation the corresponding map
+// entry would (e.g. durations as accepted by time.ParseDuration, booleans
+// as the literal "true"); an empty field behaves like an absent map key.
+// Parsing, defaulting, and connection validation happen when the Config is
+// used to construct a data source (NewI2b2DataSourceFromConfig), exactly as
+// they do for the map form.
+type Config struct {
+	I2b2URL                       string
+	I2b2Domain                    string
+	I2b2Username                  string
+	I2b2Password                  string
+	I2b2Project                   string
+	I2b2WaitTime                  string
+	I2b2HTTPTimeout               string
+	I2b2MaxRetries                string
+	I2b2RetryBaseDelay            string
+	I2b2MaxConcurrentRequests     string
+	I2b2TLSClientCert             string
+	I2b2TLSClientKey              string
+	I2b2TLSCAFile                 string
+	I2b2TLSInsecureSkipVerify     string
+	I2b2ProxyURL                  string
+	I2b2GzipRequests              string
+	I2b2DumpMessages              string
+	I2b2UserAgent                 string
+	I2b2TraceHeaderName           string
+	I2b2PollIntervalMin           string
+	I2b2PollIntervalMax           string
+	I2b2MaxPollCount              string
+	I2b2MaxResponseBytes          string
+	I2b2AllowedDemographicFields  string
+	I2b2OntCacheTTL               string
+	I2b2OntMaxElements            string
+	I2b2ExploreCacheTTL           string
+	I2b2CountSuppressionThreshold string
+	I2b2VersionCompatible         string
+	I2b2ApplicationVersion        string
+	I2b2DateTimeFormat            string
+	I2b2Timezone                  string
+
+	DBHost            string
+	DBPort            string
+	DBUser            string
+	DBPassword        string
+	DBName            string
+	DBMaxOpenConns    string
+	DBMaxIdleConns    string
+	DBConnMaxLifetime string
+	DBSSLMode         string
+	DBSSLRootCert     string
+	DBSSLCert         string
+	DBSSLKey          string
+}
+
+// configFields is the single source of truth mapping each recognized flat
+// config key to its Config field, so ConfigFromMap and Config.ToMap can't
+// drift apart into two independently maintained lists.
+var configFields = []struct {
+	key   string
+	field func(*Config) *string
+}{
+	{"i2b2.api.url", func(c *Config) *string { return &c.I2b2URL }},
+	{"i2b2.api.domain", func(c *Config) *string { return &c.I2b2Domain }},
+	{"i2b2.api.username", func(c *Config) *string { return &c.I2b2Username }},
+	{"i2b2.api.password", func(c *Config) *string { return &c.I2b2Password }},
+	{"i2b2.api.project", func(c *Config) *string { return &c.I2b2Project }},
+	{"i2b2.api.wait-time", func(c *Config) *string { return &c.I2b2WaitTime }},
+	{"i2b2.api.http-timeout", func(c *Config) *string { return &c.I2b2HTTPTimeout }},
+	{"i2b2.api.max-retries", func(c *Config) *string { return &c.I2b2MaxRetries }},
+	{"i2b2.api.retry-base-delay", func(c *Config) *string { return &c.I2b2RetryBaseDelay }},
+	{"i2b2.api.max-concurrent-requests", func(c *Config) *string { return &c.I2b2MaxConcurrentRequests }},
+	{"i2b2.api.tls.client-cert", func(c *Config) *string { return &c.I2b2TLSClientCert }},
+	{"i2b2.api.tls.client-key", func(c *Config) *string { return &c.I2b2TLSClientKey }},
+	{"i2b2.api.tls.ca-file", func(c *Config) *string { return &c.I2b2TLSCAFile }},
+	{"i2b2.api.tls.insecure-skip-verify", func(c *Config) *string { return &c.I2b2TLSInsecureSkipVerify }},
+	{"i2b2.api.proxy-url", func(c *Config) *string { return &c.I2b2ProxyURL }},
+	{"i2b2.api.gzip-requests", func(c *Config) *string { return &c.I2b2GzipRequests }},
+	{"i2b2.api.dump-messages", func(c *Config) *string { return &c.I2b2DumpMessages }},
+	{"i2b2.api.user-agent", func(c *Config) *string { return &c.I2b2UserAgent }},
+	{"i2b2.api.trace-header-name", func(c *Config) *string { return &c.I2b2TraceHeaderName }},
+	{"i2b2.api.poll-interval-min", func(c *Config) *string { return &c.I2b2PollIntervalMin }},
+	{"i2b2.api.poll-interval-max", func(c *Config) *string { return &c.I2b2PollIntervalMax }},
+	{"i2b2.api.max-poll-count", func(c *Config) *string { return &c.I2b2MaxPollCount }},
+	{"i2b2.api.max-response-bytes", func(c *Config) *string { return &c.I2b2MaxResponseBytes }},
+	{"i2b2.api.allowed-demographic-fields", func(c *Config) *string { return &c.I2b2AllowedDemographicFields }},
+	{"i2b2.api.ont-cache-ttl", func(c *Config) *string { return &c.I2b2OntCacheTTL }},
+	{"i2b2.api.ont-max-elements", func(c *Config) *string { return &c.I2b2OntMaxElements }},
+	{"i2b2.api.explore-cache-ttl", func(c *Config) *string { return &c.I2b2ExploreCacheTTL }},
+	{"i2b2.api.count-suppression-threshold", func(c *Config) *string { return &c.I2b2CountSuppressionThreshold }},
+	{"i2b2.api.version-compatible", func(c *Config) *string { return &c.I2b2VersionCompatible }},
+	{"i2b2.api.application-version", func(c *Config) *string { return &c.I2b2ApplicationVersion }},
+	{"i2b2.api.datetime-format", func(c *Config) *string { return &c.I2b2DateTimeFormat }},
+	{"i2b2.api.timezone", func(c *Config) *string { return &c.I2b2Timezone }},
+
+	{"db.host", func(c *Config) *string { return &c.DBHost }},
+	{"db.port", func(c *Config) *string { return &c.DBPort }},
+	{"db.user", func(c *Config) *string { return &c.DBUser }},
+	{"db.password", func(c *Config) *string { return &c.DBPassword }},
+	{"db.name", func(c *Config) *string { return &c.DBName }},
+	{"db.max-open-conns", func(c *Config) *string { return &c.DBMaxOpenConns }},
+	{"db.max-idle-conns", func(c *Config) *string { return &c.DBMaxIdleConns }},
+	{"db.conn-max-lifetime", func(c *Config) *string { return &c.DBConnMaxLifetime }},
+	{"db.ssl-mode", func(c *Config) *string { return &c.DBSSLMode }},
+	{"db.ssl-root-cert", func(c *Config) *string { return &c.DBSSLRootCert }},
+	{"db.ssl-cert", func(c *Config) *string { return &c.DBSSLCert }},
+	{"db.ssl-key", func(c *Config) *string { return &c.DBSSLKey }},
+}
+
+// ConfigFromMap builds a Config from the flat map NewI2b2DataSource
+// accepts, copying every key it recognizes into the matching field and
+// returning the rest as warnings (formatted, human-readable messages), so a
+// typo'd key name is reported instead of silently behaving as unset.
+// Callers that want misconfiguration to be fatal can treat a non-empty
+// warnings slice as an error; NewI2b2DataSource itself only logs them.
+func ConfigFromMap(config map[string]string) (Config, []string) {
+	var cfg Config
+	for _, f := range configFields {
+		*f.field(&cfg) = config[f.key]
+	}
+
+	var warnings []string
+	for key := range config {
+		known := false
+		for _, f := range configFields {
+			if f.key == key {
+				known = true
+				break
+			}
+		}
+		if !known {
+			warnings = append(warnings, fmt.Sprintf("i2b2datasource: unrecognized config key %q", key))
+		}
+	}
+	return cfg, warnings
+}
+
+// ToMap converts cfg back to the flat map form, for the existing
+// per-key parsing helpers (newHandlers, NewPostgresDatabase, ...) that
+// thread configuration as map[string]string. Empty fields are omitted, so
+// they're indistinguishable from an absent map key.
+func (cfg Config) ToMap() map[string]string {
+	m := make(map[string]string, len(configFields))
+	for _, f := range configFields {
+		if v := *f.field(&cfg); v != "" {
+			m[f.key] = v
+		}
+	}
+	return m
+}
+
+// NewI2b2DataSourceFromConfig builds an I2b2DataSource from cfg, the typed
+// alternative to NewI2b2DataSource's flat map. It is equivalent to calling
+// NewI2b2DataSource with cfg.ToMap().
+func NewI2b2DataSourceFromConfig(cfg Config) (*I2b2DataSource, error) {
+	return NewI2b2DataSource(cfg.ToMap())
+}
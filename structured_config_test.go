@@ -0,0 +1,35 @@
+package i2b2datasource
+
+import "testing"
+
+func TestConfigFromMapRoundTrip(t *testing.T) {
+	config := map[string]string{
+		"i2b2.api.url":      "https://hive.example.org",
+		"i2b2.api.domain":   "i2b2demo",
+		"i2b2.api.username": "demo",
+		"db.host":           "localhost",
+		"db.port":           "5432",
+	}
+
+	cfg, warnings := ConfigFromMap(config)
+	if len(warnings) != 0 {
+		t.Fatalf("warnings = %v, want none", warnings)
+	}
+	if cfg.I2b2URL != "https://hive.example.org" || cfg.I2b2Domain != "i2b2demo" || cfg.I2b2Username != "demo" {
+		t.Fatalf("cfg = %+v, missing expected i2b2 fields", cfg)
+	}
+	if cfg.DBHost != "localhost" || cfg.DBPort != "5432" {
+		t.Fatalf("cfg = %+v, missing expected db fields", cfg)
+	}
+
+	if got := cfg.ToMap(); len(got) != len(config) {
+		t.Fatalf("ToMap() = %v, want a map with %d entries", got, len(config))
+	}
+}
+
+func TestConfigFromMapReportsUnknownKeys(t *testing.T) {
+	_, warnings := ConfigFromMap(map[string]string{"i2b2.api.urll": "typo"})
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want exactly one", warnings)
+	}
+}
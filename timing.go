@@ -0,0 +1,52 @@
+package i2b2datasource
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// operationTimers accumulates the time a single Query call spends in the
+// database, across however many PostgresDatabase calls the dispatched
+// handler makes, so Query can log it alongside the i2b2 time tracked via
+// i2b2client.DurationAccumulator and tell which backend dominated an
+// operation's duration. Safe for concurrent use.
+type operationTimers struct {
+	dbNanos int64
+}
+
+// addDB accumulates d. A nil receiver is a no-op, so PostgresDatabase
+// doesn't need to nil-check before accumulating into whatever
+// operationTimersFromContext returns.
+func (t *operationTimers) addDB(d time.Duration) {
+	if t == nil {
+		return
+	}
+	atomic.AddInt64(&t.dbNanos, int64(d))
+}
+
+// dbDuration returns the total database time accumulated so far.
+func (t *operationTimers) dbDuration() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&t.dbNanos))
+}
+
+// operationTimersContextKey is the unexported context key
+// contextWithOperationTimers stores its value under.
+type operationTimersContextKey struct{}
+
+// contextWithOperationTimers returns a copy of ctx carrying t, so
+// PostgresDatabase calls made with it accumulate their duration into t
+// without widening every method signature to carry it explicitly.
+func contextWithOperationTimers(ctx context.Context, t *operationTimers) context.Context {
+	return context.WithValue(ctx, operationTimersContextKey{}, t)
+}
+
+// operationTimersFromContext returns the operationTimers attached by
+// contextWithOperationTimers, or nil when ctx carries none.
+func operationTimersFromContext(ctx context.Context) *operationTimers {
+	t, _ := ctx.Value(operationTimersContextKey{}).(*operationTimers)
+	return t
+}
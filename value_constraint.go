@@ -0,0 +1,89 @@
+package i2b2datasource
+
+import "fmt"
+
+// ValueOperator is a comparison operator for an item's constrain_by_value.
+type ValueOperator string
+
+const (
+	ValueOperatorEQ      ValueOperator = "EQ"
+	ValueOperatorNE      ValueOperator = "NE"
+	ValueOperatorGT      ValueOperator = "GT"
+	ValueOperatorLT      ValueOperator = "LT"
+	ValueOperatorGE      ValueOperator = "GE"
+	ValueOperatorLE      ValueOperator = "LE"
+	ValueOperatorBETWEEN ValueOperator = "BETWEEN"
+)
+
+// ValueType is the i2b2 value type a constrain_by_value applies to.
+type ValueType string
+
+const (
+	ValueTypeNumber ValueType = "NUMBER"
+	ValueTypeText   ValueType = "TEXT"
+	ValueTypeFlag   ValueType = "FLAG"
+)
+
+// ValueConstraint constrains an Item to observations whose value matches
+// Operator against Value (or Low/High for BETWEEN), interpreted as Type.
+// Unit is only meaningful for ValueTypeNumber.
+type ValueConstraint struct {
+	Operator ValueOperator
+	Type     ValueType
+	Value    string
+	Low      string
+	High     string
+	Unit     string
+}
+
+// Validate rejects malformed operator/value-type combinations: BETWEEN
+// requires both Low and High and no Value, every other operator requires
+// Value and neither bound, and TEXT/FLAG values only support EQ/NE.
+func (vc ValueConstraint) Validate() error {
+	if vc.Operator == ValueOperatorBETWEEN {
+		if vc.Low == "" || vc.High == "" {
+			return fmt.Errorf("i2b2datasource: BETWEEN constraint requires both a low and high bound")
+		}
+		if vc.Value != "" {
+			return fmt.Errorf("i2b2datasource: BETWEEN constraint must not set Value")
+		}
+	} else {
+		if vc.Value == "" {
+			return fmt.Errorf("i2b2datasource: %s constraint requires a Value", vc.Operator)
+		}
+		if vc.Low != "" || vc.High != "" {
+			return fmt.Errorf("i2b2datasource: %s constraint must not set Low/High", vc.Operator)
+		}
+	}
+
+	switch vc.Type {
+	case ValueTypeNumber:
+		// any operator is valid for numeric values
+	case ValueTypeText, ValueTypeFlag:
+		if vc.Operator != ValueOperatorEQ && vc.Operator != ValueOperatorNE {
+			return fmt.Errorf("i2b2datasource: value type %s only supports EQ/NE, got %s", vc.Type, vc.Operator)
+		}
+	default:
+		return fmt.Errorf("i2b2datasource: unknown value type %q", vc.Type)
+	}
+	return nil
+}
+
+// buildXML renders vc as the <constrain_by_value> element expected by the
+// CRC cell.
+func (vc ValueConstraint) buildXML() string {
+	if vc.Operator == ValueOperatorBETWEEN {
+		return fmt.Sprintf(`<constrain_by_value>
+          <value_operator>%s</value_operator>
+          <value_constraint>%s</value_constraint>
+          <value_unit_of_measure>%s</value_unit_of_measure>
+          <value_type>%s</value_type>
+        </constrain_by_value>`, vc.Operator, escapeXML(vc.Low)+","+escapeXML(vc.High), escapeXML(vc.Unit), vc.Type)
+	}
+	return fmt.Sprintf(`<constrain_by_value>
+      <value_operator>%s</value_operator>
+      <value_constraint>%s</value_constraint>
+      <value_unit_of_measure>%s</value_unit_of_measure>
+      <value_type>%s</value_type>
+    </constrain_by_value>`, vc.Operator, escapeXML(vc.Value), escapeXML(vc.Unit), vc.Type)
+}
@@ -0,0 +1,24 @@
+package i2b2datasource
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValueConstraintBuildXMLEscapesValue checks that a value containing
+// XML metacharacters is escaped rather than spliced verbatim into
+// <value_constraint>, where a crafted value could otherwise close the
+// element early and append an unconstrained item/panel to the query.
+func TestValueConstraintBuildXMLEscapesValue(t *testing.T) {
+	vc := ValueConstraint{
+		Operator: ValueOperatorEQ,
+		Type:     ValueTypeText,
+		Value:    `foo</value_constraint></item><item><item_key>\PCORI\ALL\</item_key></item>`,
+	}
+
+	xml := vc.buildXML()
+
+	if strings.Contains(xml, "</value_constraint></item>") {
+		t.Fatalf("buildXML() = %s, want the value escaped, not closing the element early", xml)
+	}
+}
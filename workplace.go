@@ -0,0 +1,59 @@
+package i2b2datasource
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ldsec/geco-i2b2-data-source/i2b2client"
+)
+
+// ListWorkplaceFoldersHandler lists the configured user's WORK cell
+// workplace folders and the items nested inside them (most commonly saved
+// query references), via the WORK cell's getWorkplace request. Rejected
+// credentials surface as an error satisfying
+// errors.Is(err, i2b2client.ErrAuthentication).
+//
+// Parameters: none.
+//
+// The result's outputNameListWorkplaceFolders entry is a tree: each node has
+// "name", "path" and "isFolder", and folders additionally have "children",
+// a possibly-empty list of nodes nested under that folder.
+func (ds *I2b2DataSource) ListWorkplaceFoldersHandler(ctx context.Context, parameters map[string]string) (map[OutputDataObjectName]interface{}, error) {
+	items, err := ds.i2b2Client.GetWorkplaceFolders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("i2b2datasource: ListWorkplaceFolders: %w", err)
+	}
+
+	return map[OutputDataObjectName]interface{}{
+		outputNameListWorkplaceFolders: buildWorkplaceTree(items),
+	}, nil
+}
+
+// buildWorkplaceTree reconstructs the nested workplace folder tree from
+// items' flat Path/ParentPath links, rooted at the top-level items (those
+// with an empty ParentPath).
+func buildWorkplaceTree(items []i2b2client.WorkplaceItem) []map[string]interface{} {
+	children := make(map[string][]i2b2client.WorkplaceItem)
+	for _, item := range items {
+		children[item.ParentPath] = append(children[item.ParentPath], item)
+	}
+
+	var nodesFor func(parentPath string) []map[string]interface{}
+	nodesFor = func(parentPath string) []map[string]interface{} {
+		items := children[parentPath]
+		nodes := make([]map[string]interface{}, len(items))
+		for i, item := range items {
+			node := map[string]interface{}{
+				"name":     item.Name,
+				"path":     item.Path,
+				"isFolder": item.IsFolder,
+			}
+			if item.IsFolder {
+				node["children"] = nodesFor(item.Path)
+			}
+			nodes[i] = node
+		}
+		return nodes
+	}
+	return nodesFor("")
+}
@@ -0,0 +1,21 @@
+package i2b2datasource
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// escapeXML escapes s for safe inclusion as XML character data between
+// tags (e.g. "<item_key>"+escapeXML(s)+"</item_key>"). QueryDefinition and
+// its nested types build CRC query XML by hand via fmt.Sprintf rather than
+// encoding/xml.Marshal, so caller-supplied values (concept paths, modifier
+// keys, constraint values, ...) must be escaped explicitly before
+// interpolation, or a value containing "<", "&", etc. produces malformed
+// XML or lets a crafted value inject sibling elements/panels into the
+// query actually sent to the hive.
+func escapeXML(s string) string {
+	var b strings.Builder
+	// xml.EscapeText never returns an error for a strings.Builder target.
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}